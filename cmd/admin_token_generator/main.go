@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,10 +10,18 @@ import (
 
 	"webhook-forge/internal/config"
 	"webhook-forge/internal/service"
+	"webhook-forge/internal/version"
 	"webhook-forge/pkg/logger"
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(version.String("admin-token-generator"))
+		return
+	}
+
 	// Load configuration
 	// Check if CONFIG_PATH environment variable is set
 	configPath := os.Getenv("CONFIG_PATH")
@@ -30,13 +39,38 @@ func main() {
 	// Initialize logger with file rotation
 	var log logger.Logger
 
-	if cfg != nil && cfg.Log.FilePath != "" {
+	if cfg != nil && (cfg.Log.FilePath != "" || len(cfg.Log.Sinks) > 0) {
 		logConfig := logger.LogConfig{
-			Level:      cfg.Log.Level,
-			Format:     cfg.Log.Format,
-			FilePath:   cfg.Log.FilePath,
-			MaxSize:    cfg.Log.MaxSize,
-			MaxBackups: cfg.Log.MaxBackups,
+			Level:           cfg.Log.Level,
+			Format:          cfg.Log.Format,
+			FilePath:        cfg.Log.FilePath,
+			MaxSize:         cfg.Log.MaxSize,
+			MaxBackups:      cfg.Log.MaxBackups,
+			MaxAgeDays:      cfg.Log.MaxAgeDays,
+			Compress:        cfg.Log.Compress,
+			RotateEvery:     cfg.Log.RotateEvery,
+			TimestampFormat: cfg.Log.TimestampFormat,
+			Tag:             cfg.Log.Tag,
+			Redact: logger.RedactConfig{
+				Keys:     cfg.Log.Redact.Keys,
+				Patterns: cfg.Log.Redact.Patterns,
+			},
+		}
+
+		for _, sink := range cfg.Log.Sinks {
+			logConfig.Sinks = append(logConfig.Sinks, logger.SinkConfig{
+				Type:        sink.Type,
+				Level:       sink.Level,
+				Format:      sink.Format,
+				FilePath:    sink.FilePath,
+				MaxSize:     sink.MaxSize,
+				MaxBackups:  sink.MaxBackups,
+				MaxAgeDays:  sink.MaxAgeDays,
+				Compress:    sink.Compress,
+				RotateEvery: sink.RotateEvery,
+				Address:     sink.Address,
+				Facility:    sink.Facility,
+			})
 		}
 
 		log, err = logger.NewWithConfig(logConfig)
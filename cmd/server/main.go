@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,13 +15,24 @@ import (
 
 	"webhook-forge/internal/api"
 	"webhook-forge/internal/config"
+	"webhook-forge/internal/domain"
+	"webhook-forge/internal/health"
+	"webhook-forge/internal/metrics"
 	"webhook-forge/internal/middleware"
 	"webhook-forge/internal/service"
 	"webhook-forge/internal/storage"
+	"webhook-forge/internal/version"
 	"webhook-forge/pkg/logger"
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println(version.String("webhook-forge"))
+		return
+	}
+
 	// Load configuration
 	// Check if CONFIG_PATH environment variable is set
 	configPath := os.Getenv("CONFIG_PATH")
@@ -34,13 +47,38 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create logger with file rotation
+	// Create logger writing to the configured sinks
 	logConfig := logger.LogConfig{
-		Level:      cfg.Log.Level,
-		Format:     cfg.Log.Format,
-		FilePath:   cfg.Log.FilePath,
-		MaxSize:    cfg.Log.MaxSize,
-		MaxBackups: cfg.Log.MaxBackups,
+		Level:           cfg.Log.Level,
+		Format:          cfg.Log.Format,
+		FilePath:        cfg.Log.FilePath,
+		MaxSize:         cfg.Log.MaxSize,
+		MaxBackups:      cfg.Log.MaxBackups,
+		MaxAgeDays:      cfg.Log.MaxAgeDays,
+		Compress:        cfg.Log.Compress,
+		RotateEvery:     cfg.Log.RotateEvery,
+		TimestampFormat: cfg.Log.TimestampFormat,
+		Tag:             cfg.Log.Tag,
+		Redact: logger.RedactConfig{
+			Keys:     cfg.Log.Redact.Keys,
+			Patterns: cfg.Log.Redact.Patterns,
+		},
+	}
+
+	for _, sink := range cfg.Log.Sinks {
+		logConfig.Sinks = append(logConfig.Sinks, logger.SinkConfig{
+			Type:        sink.Type,
+			Level:       sink.Level,
+			Format:      sink.Format,
+			FilePath:    sink.FilePath,
+			MaxSize:     sink.MaxSize,
+			MaxBackups:  sink.MaxBackups,
+			MaxAgeDays:  sink.MaxAgeDays,
+			Compress:    sink.Compress,
+			RotateEvery: sink.RotateEvery,
+			Address:     sink.Address,
+			Facility:    sink.Facility,
+		})
 	}
 
 	log, err := logger.NewWithConfig(logConfig)
@@ -50,7 +88,13 @@ func main() {
 	}
 	defer log.Close()
 
-	log.Info("Starting webhook-forge server")
+	// Route log/slog (and the standard log package) through the same logger
+	logger.SetDefault(log)
+	slog.SetDefault(slog.New(logger.NewSlogHandler(log)))
+
+	log.Info("Starting webhook-forge server",
+		logger.Field{Key: "version", Value: version.Version},
+		logger.Field{Key: "commit", Value: version.Commit})
 
 	// Create hooks directory
 	hooksDir := filepath.Dir(cfg.Hooks.StoragePath)
@@ -64,13 +108,69 @@ func main() {
 	}
 
 	// Create hook repository
-	hookRepo, err := storage.NewJSONHookRepository(cfg.Hooks.StoragePath)
-	if err != nil {
-		log.Fatal("Failed to create hook repository", logger.Field{Key: "error", Value: err.Error()})
+	var hookRepo domain.HookRepository
+	var jsonRepo *storage.JSONHookRepository
+	switch cfg.Hooks.StorageDriver {
+	case "", "json":
+		jsonRepo, err = storage.NewJSONHookRepository(cfg.Hooks.StoragePath, log)
+		if err != nil {
+			log.Fatal("Failed to create hook repository", logger.Field{Key: "error", Value: err.Error()})
+		}
+		hookRepo = jsonRepo
+	case "bolt":
+		boltRepo, err := storage.NewBoltHookRepository(cfg.Hooks.DatabasePath, log)
+		if err != nil {
+			log.Fatal("Failed to create hook repository", logger.Field{Key: "error", Value: err.Error()})
+		}
+		defer boltRepo.Close()
+
+		// Import hooks from an existing JSON file on first start
+		if _, err := boltRepo.ImportJSONFile(cfg.Hooks.StoragePath); err != nil {
+			log.Fatal("Failed to migrate hooks to database", logger.Field{Key: "error", Value: err.Error()})
+		}
+		hookRepo = boltRepo
+	case "sqlite", "postgres":
+		sqlRepo, err := storage.NewSQLHookRepository(cfg.Hooks.StorageDriver, cfg.Hooks.StorageDSN, log)
+		if err != nil {
+			log.Fatal("Failed to create hook repository", logger.Field{Key: "error", Value: err.Error()})
+		}
+		defer sqlRepo.Close()
+
+		// Import hooks from an existing JSON file on first start
+		if _, err := sqlRepo.ImportJSONFile(cfg.Hooks.StoragePath); err != nil {
+			log.Fatal("Failed to migrate hooks to database", logger.Field{Key: "error", Value: err.Error()})
+		}
+		hookRepo = sqlRepo
+	default:
+		log.Fatal("Unknown storage driver", logger.Field{Key: "storage_driver", Value: cfg.Hooks.StorageDriver})
 	}
+	log.Info("Hook storage initialized", logger.Field{Key: "storage_driver", Value: cfg.Hooks.StorageDriver})
+
+	// Metrics are always recorded and only served when enabled
+	m := metrics.New()
 
 	// Create hook service
-	hookService := service.NewHookService(hookRepo, cfg.Hooks.FlagsDir, log)
+	hookService := service.NewHookService(hookRepo, cfg.Hooks.FlagsDir, log, m)
+
+	// Watch hooks file for external changes
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if jsonRepo != nil {
+		jsonRepo.SetHookValidator(hookService.ValidateHook)
+		jsonRepo.SetHookIDValidator(hookService.ValidateHookID)
+		if cfg.Hooks.ReloadInterval > 0 {
+			go jsonRepo.Watch(watchCtx, time.Duration(cfg.Hooks.ReloadInterval)*time.Second)
+		}
+	}
+
+	// Reconcile hooks declared in the provisioning directory
+	var provisioner *service.Provisioner
+	if cfg.Hooks.ProvisionDir != "" {
+		provisioner = service.NewProvisioner(cfg.Hooks.ProvisionDir, hookService, log)
+		if _, err := provisioner.Reconcile(context.Background(), false); err != nil {
+			log.Fatal("Failed to provision hooks", logger.Field{Key: "directory", Value: cfg.Hooks.ProvisionDir}, logger.Field{Key: "error", Value: err.Error()})
+		}
+	}
 
 	// Verify that admin token is set
 	if cfg.Server.AdminToken == "" {
@@ -79,14 +179,34 @@ func main() {
 
 	// Create API handler
 	handler := api.NewHandler(hookService, log, cfg.Server.BasePath, cfg.Server.AdminToken)
+	if provisioner != nil {
+		handler.SetProvisioner(provisioner)
+	}
+	handler.SetDebugHooks(cfg.Log.DebugHooks)
+	handler.SetRequireIfMatch(cfg.Server.RequireIfMatch)
+	handler.SetConfigSummary(cfg.Redacted())
+
+	// Register readiness checks; the handler always checks that storage is readable
+	handler.AddHealthCheck("flags_dir", health.WritableDir(cfg.Hooks.FlagsDir))
+	if jsonRepo != nil {
+		handler.AddHealthCheck("hooks_file", func(ctx context.Context) error {
+			return jsonRepo.CheckFile()
+		})
+	}
+	if cfg.Health.MinFreeDiskMB > 0 {
+		minFree := uint64(cfg.Health.MinFreeDiskMB) << 20
+		handler.AddHealthCheck("disk_flags_dir", health.DiskSpace(cfg.Hooks.FlagsDir, minFree))
+		if jsonRepo != nil {
+			handler.AddHealthCheck("disk_storage", health.DiskSpace(filepath.Dir(cfg.Hooks.StoragePath), minFree))
+		}
+	}
 
 	// Create HTTP server
 	mux := http.NewServeMux()
 
 	// Create middlewares
-	requestLogger := middleware.NewRequestLogger(log)
-	adminAuth := middleware.NewAdminAuth(log, cfg.Server.AdminToken)
-	webhookAuth := middleware.NewWebhookAuth(log, hookService)
+	adminAuth := middleware.NewAdminAuth(log, cfg.Server.AdminToken, m)
+	webhookAuth := middleware.NewWebhookAuth(log, hookService, m)
 
 	log.Info("Initialized authentication middlewares")
 
@@ -96,7 +216,7 @@ func main() {
 
 	// Set up webhook routes with webhook authentication
 	webhookRoutes := handler.GetWebhookRoutes()
-	webhookRoutesWithAuth := webhookAuth.Middleware(webhookRoutes)
+	webhookRoutesWithAuth := handler.DebugWebhooks(webhookAuth.Middleware(webhookRoutes))
 
 	// Set up health check route without authentication
 	healthHandler := handler.GetHealthHandler()
@@ -105,6 +225,7 @@ func main() {
 	apiPath := cfg.Server.BasePath + "/api"
 	webhookPath := cfg.Server.BasePath + "/webhook"
 	healthPath := cfg.Server.BasePath + "/health"
+	metricsPath := cfg.Server.BasePath + "/metrics"
 
 	// Ensure paths are properly formatted
 	if apiPath != "" && !strings.HasPrefix(apiPath, "/") {
@@ -116,16 +237,31 @@ func main() {
 	if healthPath != "" && !strings.HasPrefix(healthPath, "/") {
 		healthPath = "/" + healthPath
 	}
+	if !strings.HasPrefix(metricsPath, "/") {
+		metricsPath = "/" + metricsPath
+	}
 	apiPath = strings.TrimSuffix(apiPath, "/")
 	webhookPath = strings.TrimSuffix(webhookPath, "/")
 	healthPath = strings.TrimSuffix(healthPath, "/")
 
 	// Register routes with authentication middleware applied
-	mux.Handle(apiPath+"/", http.StripPrefix(apiPath, apiRoutesWithAuth))
-	mux.Handle(webhookPath+"/", http.StripPrefix(webhookPath, webhookRoutesWithAuth))
-	mux.Handle(healthPath+"/", http.StripPrefix(healthPath, healthHandler))
+	mux.Handle(apiPath+"/", middleware.Route("/api", http.StripPrefix(apiPath, apiRoutesWithAuth)))
+	mux.Handle(webhookPath+"/", middleware.Route("/webhook", http.StripPrefix(webhookPath, webhookRoutesWithAuth)))
+	mux.Handle(healthPath+"/", middleware.Route("/health", http.StripPrefix(healthPath, healthHandler)))
 
-	// Apply request logging middleware to all requests
+	// Serve metrics, behind a bearer token if one is configured
+	if cfg.Metrics.Enabled {
+		var metricsHandler http.Handler = m.Handler()
+		if cfg.Metrics.Token != "" {
+			metricsHandler = middleware.NewMetricsAuth(log, cfg.Metrics.Token, m).Middleware(metricsHandler)
+		}
+		mux.Handle("GET "+metricsPath, middleware.Route("/metrics", metricsHandler))
+		log.Info("Metrics enabled", logger.Field{Key: "path", Value: metricsPath}, logger.Field{Key: "auth", Value: cfg.Metrics.Token != ""})
+	}
+
+	// Apply request logging middleware to all requests; successful health
+	// probes are logged at debug level
+	requestLogger := middleware.NewRequestLogger(log, m, healthPath+"/")
 	middlewareChain := requestLogger.Middleware(mux)
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -145,6 +281,43 @@ func main() {
 		}
 	}()
 
+	// Reopen the log file and reconcile provisioned hooks again on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			if err := log.Reopen(); err != nil {
+				log.Error("Failed to reopen log file", logger.Field{Key: "error", Value: err.Error()})
+			}
+			log.Info("Received SIGHUP")
+			if provisioner != nil {
+				// Errors are logged by the provisioner; the current hooks stay active
+				provisioner.Reconcile(context.Background(), false)
+			}
+		}
+	}()
+
+	// Toggle debug logging on SIGUSR1; the next signal restores the previous level
+	if len(debugSignals) > 0 {
+		toggle := make(chan os.Signal, 1)
+		signal.Notify(toggle, debugSignals...)
+		go func() {
+			restore := log.Level()
+			for range toggle {
+				if log.Level() == logger.DebugLevel {
+					if restore == logger.DebugLevel {
+						restore = logger.InfoLevel
+					}
+					log.SetLevel(restore)
+				} else {
+					restore = log.Level()
+					log.SetLevel(logger.DebugLevel)
+				}
+				log.WithLevel(logger.InfoLevel).Info("Log level changed by signal", logger.Field{Key: "log_level", Value: log.Level().String()})
+			}
+		}()
+	}
+
 	// Set up graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
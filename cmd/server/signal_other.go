@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// debugSignals is empty on platforms without SIGUSR1; use PUT /api/log/level instead
+var debugSignals []os.Signal
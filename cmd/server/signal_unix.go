@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// debugSignals toggle debug logging
+var debugSignals = []os.Signal{syscall.SIGUSR1}
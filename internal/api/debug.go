@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"webhook-forge/pkg/logger"
+)
+
+// maxDebugBodySize limits how much of a body per-hook debug logging records
+const maxDebugBodySize = 64 << 10
+
+// debugRecorder captures the status, and the start of the body, of a response
+type debugRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader captures the status code
+func (rec *debugRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write captures up to maxDebugBodySize bytes of the body
+func (rec *debugRecorder) Write(b []byte) (int, error) {
+	if room := maxDebugBodySize - rec.body.Len(); room > 0 {
+		rec.body.Write(b[:min(len(b), room)])
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// DebugWebhooks returns a middleware that logs the headers and bodies of
+// webhook requests and their responses for hooks selected with SetDebugHooks
+// or PUT /api/log/level. It runs before authentication, so rejected requests
+// are logged as well. Entries are written at debug level even if the log
+// level is higher; secrets such as the token query parameter and the
+// Authorization header are redacted.
+func (h *Handler) DebugWebhooks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := path.Base(r.URL.Path)
+		if !h.debugHooks.has(id) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log := h.log(r).WithLevel(logger.DebugLevel).WithField("id", id)
+
+		// Read the start of the body and hand the full body on unchanged
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxDebugBodySize+1))
+		if err != nil {
+			log.Warn("Failed to read webhook request body for debugging",
+				logger.Field{Key: "error", Value: err.Error()})
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+		log.Debug("Webhook request",
+			logger.Field{Key: "method", Value: r.Method},
+			logger.Field{Key: "path", Value: r.URL.Path},
+			logger.Field{Key: "query", Value: logger.Query(r.URL.Query())},
+			logger.Field{Key: "headers", Value: flattenHeader(r.Header)},
+			logger.Field{Key: "body", Value: string(body[:min(len(body), maxDebugBodySize)])},
+			logger.Field{Key: "body_truncated", Value: len(body) > maxDebugBodySize})
+
+		rec := &debugRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		log.Debug("Webhook response",
+			logger.Field{Key: "status", Value: rec.status},
+			logger.Field{Key: "headers", Value: flattenHeader(w.Header())},
+			logger.Field{Key: "body", Value: rec.body.String()})
+	})
+}
+
+// flattenHeader joins repeated header values so headers log as a flat object
+func flattenHeader(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
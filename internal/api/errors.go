@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/jsonpatch"
+	"webhook-forge/pkg/logger"
+)
+
+// respondServiceError maps an error returned by the hook service to an HTTP
+// response. Client errors are logged as warnings, everything else as errors
+// and reported as 500 with the given action in the message.
+func (h *Handler) respondServiceError(w http.ResponseWriter, r *http.Request, id string, action string, err error) {
+	var validationErr *domain.ValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		h.log(r).Warn("Invalid hook",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondJSON(w, http.StatusUnprocessableEntity, domain.NewValidationErrorResponse("Invalid hook", validationErr.Fields))
+
+	case errors.Is(err, domain.ErrHookNotFound):
+		h.log(r).Warn("Hook not found",
+			logger.Field{Key: "id", Value: id})
+		h.respondError(w, http.StatusNotFound, "Hook not found")
+
+	case errors.Is(err, domain.ErrHookExists):
+		h.log(r).Warn("Hook already exists",
+			logger.Field{Key: "id", Value: id})
+		h.respondJSON(w, http.StatusConflict, domain.NewValidationErrorResponse("Hook already exists", map[string]string{"id": "already exists"}))
+
+	case errors.Is(err, domain.ErrHookManaged):
+		h.log(r).Warn("Hook is managed by provisioning",
+			logger.Field{Key: "id", Value: id})
+		h.respondError(w, http.StatusConflict, "Hook is managed by provisioning and cannot be changed through the API")
+
+	case errors.Is(err, domain.ErrRevisionMismatch):
+		h.log(r).Warn("Hook revision mismatch",
+			logger.Field{Key: "id", Value: id})
+		h.respondError(w, http.StatusPreconditionFailed, "Hook has been modified")
+
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		h.log(r).Warn("Hook patch test failed",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusConflict, "Patch test failed: "+err.Error())
+
+	case errors.Is(err, domain.ErrInvalidCursor):
+		h.log(r).Warn("Invalid cursor",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid cursor")
+
+	case errors.Is(err, domain.ErrInvalidPatch):
+		h.log(r).Warn("Invalid hook patch",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid patch: "+err.Error())
+
+	default:
+		h.log(r).Error("Failed to "+action+" hook",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusInternalServerError, "Failed to "+action+" hook: "+err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// Errors returned by parseIfMatch
+var (
+	errInvalidIfMatch = errors.New("invalid If-Match header")
+	errWeakIfMatch    = errors.New("weak entity tags are not allowed in If-Match")
+)
+
+// hookETag builds the entity tag for a hook from its revision
+func hookETag(hook *domain.Hook) string {
+	return `"` + strconv.FormatInt(hook.Revision, 10) + `"`
+}
+
+// setHookETag sets the ETag response header for a hook
+func setHookETag(w http.ResponseWriter, hook *domain.Hook) {
+	w.Header().Set("ETag", hookETag(hook))
+}
+
+// parseIfMatch extracts the expected hook revision from the If-Match header.
+// A missing header or "*" yields 0, which means the write is unconditional.
+func parseIfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	// If-Match uses strong comparison, so weak validators never match
+	// (RFC 7232, section 3.1)
+	if strings.HasPrefix(value, "W/") {
+		return 0, errWeakIfMatch
+	}
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errInvalidIfMatch
+	}
+
+	revision, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, errInvalidIfMatch
+	}
+
+	return revision, nil
+}
+
+// ifMatchRevision returns the revision from the If-Match header for a write to
+// hook id. If the header is invalid, or required and missing, it responds
+// with 412 or 428 Precondition Required and returns false. "*" does not
+// satisfy the requirement, as it matches any revision.
+func (h *Handler) ifMatchRevision(w http.ResponseWriter, r *http.Request, id string, required bool) (int64, bool) {
+	revision, err := parseIfMatch(r)
+	if err != nil {
+		h.log(r).Warn("Invalid If-Match header",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "if_match", Value: r.Header.Get("If-Match")},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusPreconditionFailed, "Invalid If-Match header: "+err.Error())
+		return 0, false
+	}
+
+	if required && revision == 0 {
+		h.log(r).Warn("Missing If-Match header",
+			logger.Field{Key: "id", Value: id})
+		h.respondError(w, http.StatusPreconditionRequired, "If-Match header with the hook's ETag is required")
+		return 0, false
+	}
+
+	return revision, true
+}
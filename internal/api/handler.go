@@ -2,11 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"io"
+	"mime"
 	"net/http"
 	"strings"
 	"time"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/health"
+	"webhook-forge/internal/middleware"
+	"webhook-forge/pkg/jsonpatch"
 	"webhook-forge/pkg/logger"
 )
 
@@ -16,6 +21,15 @@ type Handler struct {
 	logger      logger.Logger
 	basePath    string
 	adminToken  string
+	provisioner domain.HookProvisioner
+	debugHooks  *debugHooks
+
+	// requireIfMatch rejects PUT, PATCH and DELETE requests without If-Match
+	requireIfMatch bool
+
+	healthChecker *health.Checker
+	startTime     time.Time
+	configSummary interface{}
 }
 
 // NewHandler creates a new handler
@@ -28,12 +42,28 @@ func NewHandler(hookService domain.HookService, logger logger.Logger, basePath s
 		basePath = strings.TrimSuffix(basePath, "/")
 	}
 
-	return &Handler{
-		hookService: hookService,
-		logger:      logger,
-		basePath:    basePath,
-		adminToken:  adminToken,
+	h := &Handler{
+		hookService:   hookService,
+		logger:        logger,
+		basePath:      basePath,
+		adminToken:    adminToken,
+		debugHooks:    &debugHooks{},
+		healthChecker: health.NewChecker(health.DefaultTimeout),
+		startTime:     time.Now(),
 	}
+	h.healthChecker.Register("storage", h.storageCheck)
+	return h
+}
+
+// SetProvisioner enables the provisioning endpoints
+func (h *Handler) SetProvisioner(provisioner domain.HookProvisioner) {
+	h.provisioner = provisioner
+}
+
+// SetRequireIfMatch makes If-Match with the hook's ETag mandatory on PUT,
+// PATCH and DELETE, so that no update is applied without a revision check
+func (h *Handler) SetRequireIfMatch(required bool) {
+	h.requireIfMatch = required
 }
 
 // GetAPIRoutes returns the API routes handler
@@ -41,22 +71,44 @@ func (h *Handler) GetAPIRoutes() http.Handler {
 	apiMux := http.NewServeMux()
 
 	// API routes
-	apiMux.HandleFunc("GET /hooks", h.getHooks)
-	apiMux.HandleFunc("GET /hooks/{id}", h.getHook)
-	apiMux.HandleFunc("POST /hooks", h.createHook)
-	apiMux.HandleFunc("PUT /hooks/{id}", h.updateHook)
-	apiMux.HandleFunc("DELETE /hooks/{id}", h.deleteHook)
+	handle(apiMux, "GET /hooks", h.getHooks)
+	handle(apiMux, "GET /hooks/{id}", h.getHook)
+	handle(apiMux, "POST /hooks", h.createHook)
+	handle(apiMux, "PUT /hooks/{id}", h.updateHook)
+	handle(apiMux, "PATCH /hooks/{id}", h.patchHook)
+	handle(apiMux, "DELETE /hooks/{id}", h.deleteHook)
+	handle(apiMux, "POST /hooks/{id}/enable", h.enableHook)
+	handle(apiMux, "POST /hooks/{id}/disable", h.disableHook)
+	handle(apiMux, "POST /hooks/{id}/regenerate-token", h.regenerateHookToken)
+	handle(apiMux, "POST /hooks/{id}/test", h.testHook)
+	handle(apiMux, "GET /hooks/export", h.exportHooks)
+	handle(apiMux, "POST /hooks/import", h.importHooks)
+	handle(apiMux, "GET /provisioning", h.getProvisioningDrift)
+	handle(apiMux, "POST /provisioning/reconcile", h.reconcileProvisioning)
+	handle(apiMux, "GET /log/level", h.getLogLevel)
+	handle(apiMux, "PUT /log/level", h.setLogLevel)
+	handle(apiMux, "GET /info", h.getInfo)
 
 	// Health check endpoint - no authentication required
-	apiMux.HandleFunc("GET /health", h.healthCheck)
+	handle(apiMux, "GET /health", h.readiness)
 
 	return apiMux
 }
 
-// GetHealthHandler returns a standalone health check handler without authentication
+// handle registers fn for pattern and records the pattern as the route of
+// matching requests, so request metrics are labelled by route
+func handle(mux *http.ServeMux, pattern string, fn http.HandlerFunc) {
+	mux.Handle(pattern, middleware.Route(pattern, fn))
+}
+
+// GetHealthHandler returns a standalone health check handler without
+// authentication. /live is for liveness probes and /ready for readiness
+// probes; the root is kept as an alias of /ready for existing monitors.
 func (h *Handler) GetHealthHandler() http.Handler {
 	healthMux := http.NewServeMux()
-	healthMux.HandleFunc("GET /", h.healthCheck)
+	handle(healthMux, "GET /live", h.liveness)
+	handle(healthMux, "GET /ready", h.readiness)
+	handle(healthMux, "GET /{$}", h.readiness)
 	return healthMux
 }
 
@@ -65,7 +117,7 @@ func (h *Handler) GetWebhookRoutes() http.Handler {
 	webhookMux := http.NewServeMux()
 
 	// Webhook route
-	webhookMux.HandleFunc("POST /{id}", h.triggerHook)
+	handle(webhookMux, "POST /{id}", h.triggerHook)
 
 	return webhookMux
 }
@@ -105,13 +157,26 @@ func (h *Handler) getClientIP(r *http.Request) string {
 	return ip
 }
 
-// respondJSON sends a JSON response
+// log returns the handler's logger with the fields of the request context,
+// such as the request ID
+func (h *Handler) log(r *http.Request) logger.Logger {
+	return h.logger.WithContext(r.Context())
+}
+
+// respondJSON sends a JSON response. API responses carry the request ID set
+// on the response by the request logger.
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	if response, ok := data.(domain.APIResponse); ok {
+		response.RequestID = w.Header().Get(middleware.RequestIDHeader)
+		data = response
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if data != nil {
 		if err := json.NewEncoder(w).Encode(data); err != nil {
-			h.logger.Error("Failed to encode response", logger.Field{Key: "error", Value: err.Error()})
+			h.logger.Error("Failed to encode response",
+				logger.Field{Key: "request_id", Value: w.Header().Get(middleware.RequestIDHeader)},
+				logger.Field{Key: "error", Value: err.Error()})
 		}
 	}
 }
@@ -144,75 +209,69 @@ func (h *Handler) verifyAdminToken(r *http.Request) bool {
 	return token == h.adminToken
 }
 
-// getHooks handles GET /api/hooks
+// getHooks handles GET /api/hooks. Hooks are sorted by ID unless requested
+// otherwise; with a limit, the response carries a cursor for the next page in
+// next_cursor and in a Link header.
 func (h *Handler) getHooks(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
 	// Authentication is handled by middleware
 
-	hooks, err := h.hookService.GetAllHooks()
+	query, err := parseHookQuery(r)
 	if err != nil {
-		h.logger.Error("Failed to get hooks",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Invalid hook query",
+			logger.Field{Key: "query", Value: logger.Query(r.URL.Query())},
 			logger.Field{Key: "error", Value: err.Error()})
-		h.respondError(w, http.StatusInternalServerError, "Failed to get hooks")
+		h.respondError(w, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	page, err := h.hookService.ListHooks(r.Context(), query)
+	if err != nil {
+		h.respondServiceError(w, r, "", "list", err)
 		return
 	}
 
-	h.logger.Info("Hooks retrieved successfully",
-		logger.Field{Key: "ip", Value: clientIP},
-		logger.Field{Key: "count", Value: len(hooks)})
-	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(hooks))
+	response := domain.NewSuccessResponse(page.Hooks)
+	if page.NextCursor != "" {
+		response.NextCursor = page.NextCursor
+		w.Header().Set("Link", "<"+nextPageURL(r, page.NextCursor)+`>; rel="next"`)
+	}
+
+	h.log(r).Info("Hooks retrieved successfully",
+		logger.Field{Key: "count", Value: len(page.Hooks)})
+	h.respondJSON(w, http.StatusOK, response)
 }
 
 // getHook handles GET /api/hooks/{id}
 func (h *Handler) getHook(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
 	// Authentication is handled by middleware
 
 	id := r.PathValue("id")
 	if id == "" {
-		h.logger.Warn("Missing hook ID in request",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Missing hook ID in request",
 			logger.Field{Key: "path", Value: r.URL.Path})
 		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
 		return
 	}
 
-	hook, err := h.hookService.GetHook(id)
+	hook, err := h.hookService.GetHook(r.Context(), id)
 	if err != nil {
-		if err == domain.ErrHookNotFound {
-			h.logger.Warn("Hook not found",
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "id", Value: id})
-			h.respondError(w, http.StatusNotFound, "Hook not found")
-			return
-		}
-		h.logger.Error("Failed to get hook",
-			logger.Field{Key: "ip", Value: clientIP},
-			logger.Field{Key: "id", Value: id},
-			logger.Field{Key: "error", Value: err.Error()})
-		h.respondError(w, http.StatusInternalServerError, "Failed to get hook")
+		h.respondServiceError(w, r, id, "get", err)
 		return
 	}
 
-	h.logger.Info("Hook retrieved successfully",
-		logger.Field{Key: "ip", Value: clientIP},
+	h.log(r).Info("Hook retrieved successfully",
 		logger.Field{Key: "id", Value: id})
+	setHookETag(w, hook)
 	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(hook))
 }
 
 // createHook handles POST /api/hooks
 func (h *Handler) createHook(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
 	// Authentication is handled by middleware
 
 	var hook domain.Hook
 	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
-		h.logger.Warn("Invalid request body",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Invalid request body",
 			logger.Field{Key: "error", Value: err.Error()})
 		h.respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
@@ -228,41 +287,38 @@ func (h *Handler) createHook(w http.ResponseWriter, r *http.Request) {
 	hook.CreatedAt = now
 	hook.UpdatedAt = now
 
-	if err := h.hookService.CreateHook(&hook); err != nil {
-		h.logger.Error("Failed to create hook",
-			logger.Field{Key: "ip", Value: clientIP},
-			logger.Field{Key: "name", Value: hook.Name},
-			logger.Field{Key: "error", Value: err.Error()})
-		h.respondError(w, http.StatusInternalServerError, "Failed to create hook: "+err.Error())
+	if err := h.hookService.CreateHook(r.Context(), &hook); err != nil {
+		h.respondServiceError(w, r, hook.ID, "create", err)
 		return
 	}
 
-	h.logger.Info("Hook created successfully",
-		logger.Field{Key: "ip", Value: clientIP},
+	h.log(r).Info("Hook created successfully",
 		logger.Field{Key: "id", Value: hook.ID},
 		logger.Field{Key: "name", Value: hook.Name})
+	setHookETag(w, &hook)
 	h.respondJSON(w, http.StatusCreated, domain.NewSuccessResponse(hook))
 }
 
 // updateHook handles PUT /api/hooks/{id}
 func (h *Handler) updateHook(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
 	// Authentication is handled by middleware
 
 	id := r.PathValue("id")
 	if id == "" {
-		h.logger.Warn("Missing hook ID in request",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Missing hook ID in request",
 			logger.Field{Key: "path", Value: r.URL.Path})
 		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
 		return
 	}
 
+	revision, ok := h.ifMatchRevision(w, r, id, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
 	var hook domain.Hook
 	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
-		h.logger.Warn("Invalid request body",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Invalid request body",
 			logger.Field{Key: "id", Value: id},
 			logger.Field{Key: "error", Value: err.Error()})
 		h.respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -272,64 +328,103 @@ func (h *Handler) updateHook(w http.ResponseWriter, r *http.Request) {
 	// Ensure ID matches path parameter
 	hook.ID = id
 
+	// The expected revision comes from If-Match only, never from the body
+	hook.Revision = revision
+
 	// Set update timestamp
 	hook.UpdatedAt = time.Now()
 
-	if err := h.hookService.UpdateHook(&hook); err != nil {
-		if err == domain.ErrHookNotFound {
-			h.logger.Warn("Hook not found",
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "id", Value: id})
-			h.respondError(w, http.StatusNotFound, "Hook not found")
-			return
-		}
-		h.logger.Error("Failed to update hook",
-			logger.Field{Key: "ip", Value: clientIP},
+	if err := h.hookService.UpdateHook(r.Context(), &hook); err != nil {
+		h.respondServiceError(w, r, id, "update", err)
+		return
+	}
+
+	h.log(r).Info("Hook updated successfully",
+		logger.Field{Key: "id", Value: id})
+	setHookETag(w, &hook)
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(hook))
+}
+
+// patchHook handles PATCH /api/hooks/{id}
+func (h *Handler) patchHook(w http.ResponseWriter, r *http.Request) {
+	// Authentication is handled by middleware
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.log(r).Warn("Missing hook ID in request",
+			logger.Field{Key: "path", Value: r.URL.Path})
+		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
+		return
+	}
+
+	revision, ok := h.ifMatchRevision(w, r, id, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	// Select patch format by content type; plain JSON is treated as a merge patch
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.MergePatchType, "application/json", "":
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		h.log(r).Warn("Unsupported patch content type",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "content_type", Value: r.Header.Get("Content-Type")})
+		w.Header().Set("Accept-Patch", jsonpatch.MergePatchType+", "+jsonpatch.JSONPatchType)
+		h.respondError(w, http.StatusUnsupportedMediaType, "Unsupported patch content type")
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log(r).Warn("Invalid request body",
 			logger.Field{Key: "id", Value: id},
 			logger.Field{Key: "error", Value: err.Error()})
-		h.respondError(w, http.StatusInternalServerError, "Failed to update hook: "+err.Error())
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	hook, err := h.hookService.PatchHook(r.Context(), id, revision, func(doc []byte) ([]byte, error) {
+		return apply(doc, patch)
+	})
+	if err != nil {
+		h.respondServiceError(w, r, id, "patch", err)
 		return
 	}
 
-	h.logger.Info("Hook updated successfully",
-		logger.Field{Key: "ip", Value: clientIP},
+	h.log(r).Info("Hook patched successfully",
 		logger.Field{Key: "id", Value: id})
+	setHookETag(w, hook)
 	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(hook))
 }
 
 // deleteHook handles DELETE /api/hooks/{id}
 func (h *Handler) deleteHook(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
 	// Authentication is handled by middleware
 
 	id := r.PathValue("id")
 	if id == "" {
-		h.logger.Warn("Missing hook ID in request",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Missing hook ID in request",
 			logger.Field{Key: "path", Value: r.URL.Path})
 		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
 		return
 	}
 
-	if err := h.hookService.DeleteHook(id); err != nil {
-		if err == domain.ErrHookNotFound {
-			h.logger.Warn("Hook not found",
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "id", Value: id})
-			h.respondError(w, http.StatusNotFound, "Hook not found")
-			return
-		}
-		h.logger.Error("Failed to delete hook",
-			logger.Field{Key: "ip", Value: clientIP},
-			logger.Field{Key: "id", Value: id},
-			logger.Field{Key: "error", Value: err.Error()})
-		h.respondError(w, http.StatusInternalServerError, "Failed to delete hook: "+err.Error())
+	revision, ok := h.ifMatchRevision(w, r, id, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	if err := h.hookService.DeleteHook(r.Context(), id, revision); err != nil {
+		h.respondServiceError(w, r, id, "delete", err)
 		return
 	}
 
-	h.logger.Info("Hook deleted successfully",
-		logger.Field{Key: "ip", Value: clientIP},
+	h.log(r).Info("Hook deleted successfully",
 		logger.Field{Key: "id", Value: id})
 	h.respondJSON(w, http.StatusNoContent, domain.NewSuccessResponse(nil))
 }
@@ -341,8 +436,7 @@ func (h *Handler) triggerHook(w http.ResponseWriter, r *http.Request) {
 	// Authentication and ID extraction are handled by middleware
 	id := r.PathValue("id")
 	if id == "" {
-		h.logger.Warn("Missing hook ID in webhook request",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Warn("Missing hook ID in webhook request",
 			logger.Field{Key: "path", Value: r.URL.Path})
 		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
 		return
@@ -352,33 +446,29 @@ func (h *Handler) triggerHook(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
 
 	// Trigger hook - token validation already done by middleware
-	if err := h.hookService.TriggerHook(id, token, clientIP); err != nil {
+	if err := h.hookService.TriggerHook(r.Context(), id, token, clientIP); err != nil {
 		// These errors should not occur as they're handled by middleware
 		// but we keep them for robustness
 		if err == domain.ErrHookNotFound {
-			h.logger.Warn("Hook not found in webhook request",
-				logger.Field{Key: "ip", Value: clientIP},
+			h.log(r).Warn("Hook not found in webhook request",
 				logger.Field{Key: "id", Value: id})
 			h.respondError(w, http.StatusNotFound, "Hook not found")
 			return
 		}
 		if err == domain.ErrInvalidToken {
-			h.logger.Warn("Invalid token in webhook request",
-				logger.Field{Key: "ip", Value: clientIP},
+			h.log(r).Warn("Invalid token in webhook request",
 				logger.Field{Key: "id", Value: id})
 			h.respondError(w, http.StatusUnauthorized, "Invalid token")
 			return
 		}
-		h.logger.Error("Failed to trigger hook",
-			logger.Field{Key: "ip", Value: clientIP},
+		h.log(r).Error("Failed to trigger hook",
 			logger.Field{Key: "id", Value: id},
 			logger.Field{Key: "error", Value: err.Error()})
 		h.respondError(w, http.StatusInternalServerError, "Failed to trigger hook: "+err.Error())
 		return
 	}
 
-	h.logger.Info("Hook triggered successfully",
-		logger.Field{Key: "ip", Value: clientIP},
+	h.log(r).Info("Hook triggered successfully",
 		logger.Field{Key: "id", Value: id})
 	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(map[string]string{"status": "success"}))
 }
@@ -1,43 +1,82 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/health"
+	"webhook-forge/internal/version"
 	"webhook-forge/pkg/logger"
 )
 
 // HealthStatus represents the health status of the service
 type HealthStatus struct {
-	Status    string    `json:"status"`
-	Version   string    `json:"version"`
-	Timestamp time.Time `json:"timestamp"`
+	Status    string                        `json:"status"`
+	Version   string                        `json:"version"`
+	Commit    string                        `json:"commit"`
+	BuildDate string                        `json:"build_date"`
+	Timestamp time.Time                     `json:"timestamp"`
+	Checks    map[string]health.CheckResult `json:"checks,omitempty"`
 }
 
-// healthCheck handles GET /api/health
-func (h *Handler) healthCheck(w http.ResponseWriter, r *http.Request) {
-	clientIP := h.getClientIP(r)
-
-	// Check hook service availability
-	_, err := h.hookService.GetAllHooks()
-
-	status := "up"
-	if err != nil {
-		status = "down"
-		h.logger.Error("Health check failed",
-			logger.Field{Key: "ip", Value: clientIP},
-			logger.Field{Key: "error", Value: err.Error()})
-	} else {
-		h.logger.Info("Health check succeeded",
-			logger.Field{Key: "ip", Value: clientIP})
+// AddHealthCheck adds a check that must pass for the service to be ready.
+// The storage check, which lists the hooks, is always registered.
+func (h *Handler) AddHealthCheck(name string, check health.CheckFunc) {
+	h.healthChecker.Register(name, check)
+}
+
+// storageCheck checks that hooks can be read from storage
+func (h *Handler) storageCheck(ctx context.Context) error {
+	_, err := h.hookService.GetAllHooks(ctx)
+	return err
+}
+
+// liveness handles GET /health/live. It reports that the process is serving
+// requests and runs no checks, so a failing dependency never restarts it.
+func (h *Handler) liveness(w http.ResponseWriter, r *http.Request) {
+	response := HealthStatus{
+		Status:    health.StatusUp,
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildDate: version.Date,
+		Timestamp: time.Now(),
 	}
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(response))
+}
+
+// readiness handles GET /health/ready, GET /health and GET /api/health. It
+// runs the health checks and responds with 503 Service Unavailable if any
+// fails. Passing checks are logged at debug level so that frequent probes
+// don't fill the log.
+func (h *Handler) readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.healthChecker.Run(r.Context())
 
 	response := HealthStatus{
-		Status:    status,
-		Version:   "1.0.0", // Можно заменить на переменную с версией приложения
+		Status:    report.Status,
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildDate: version.Date,
 		Timestamp: time.Now(),
+		Checks:    report.Checks,
 	}
 
-	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(response))
+	if report.Status == health.StatusUp {
+		h.log(r).Debug("Health check succeeded")
+		h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(response))
+		return
+	}
+
+	var errors []string
+	for _, name := range report.Failed() {
+		errors = append(errors, fmt.Sprintf("%s: %s", name, report.Checks[name].Error))
+	}
+	h.log(r).Warn("Health check failed",
+		logger.Field{Key: "errors", Value: errors})
+
+	apiResponse := domain.NewErrorResponse(errors...)
+	apiResponse.Data = response
+	h.respondJSON(w, http.StatusServiceUnavailable, apiResponse)
 }
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// maxTestPayloadSize limits the sample payload accepted by the test endpoint
+const maxTestPayloadSize = 1 << 20
+
+// enableHook handles POST /api/hooks/{id}/enable
+func (h *Handler) enableHook(w http.ResponseWriter, r *http.Request) {
+	h.hookAction(w, r, "enable", func(id string, revision int64) (*domain.Hook, error) {
+		return h.hookService.SetHookEnabled(r.Context(), id, true, revision)
+	})
+}
+
+// disableHook handles POST /api/hooks/{id}/disable
+func (h *Handler) disableHook(w http.ResponseWriter, r *http.Request) {
+	h.hookAction(w, r, "disable", func(id string, revision int64) (*domain.Hook, error) {
+		return h.hookService.SetHookEnabled(r.Context(), id, false, revision)
+	})
+}
+
+// regenerateHookToken handles POST /api/hooks/{id}/regenerate-token
+func (h *Handler) regenerateHookToken(w http.ResponseWriter, r *http.Request) {
+	h.hookAction(w, r, "regenerate-token", func(id string, revision int64) (*domain.Hook, error) {
+		return h.hookService.RegenerateHookToken(r.Context(), id, revision)
+	})
+}
+
+// hookAction runs a state-changing action on a single hook and responds with the updated hook
+func (h *Handler) hookAction(w http.ResponseWriter, r *http.Request, action string, fn func(id string, revision int64) (*domain.Hook, error)) {
+	// Authentication is handled by middleware
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.log(r).Warn("Missing hook ID in request",
+			logger.Field{Key: "path", Value: r.URL.Path})
+		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
+		return
+	}
+
+	revision, ok := h.ifMatchRevision(w, r, id, false)
+	if !ok {
+		return
+	}
+
+	hook, err := fn(id, revision)
+	if err != nil {
+		h.respondServiceError(w, r, id, action, err)
+		return
+	}
+
+	h.log(r).Info("Hook action completed successfully",
+		logger.Field{Key: "id", Value: id},
+		logger.Field{Key: "action", Value: action})
+	setHookETag(w, hook)
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(hook))
+}
+
+// testHook handles POST /api/hooks/{id}/test
+func (h *Handler) testHook(w http.ResponseWriter, r *http.Request) {
+	clientIP := h.getClientIP(r)
+
+	// Authentication is handled by middleware; the hook token is not required
+
+	id := r.PathValue("id")
+	if id == "" {
+		h.log(r).Warn("Missing hook ID in request",
+			logger.Field{Key: "path", Value: r.URL.Path})
+		h.respondError(w, http.StatusBadRequest, "Missing hook ID")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxTestPayloadSize+1))
+	if err != nil {
+		h.log(r).Warn("Invalid request body",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(payload) > maxTestPayloadSize {
+		h.log(r).Warn("Test payload too large",
+			logger.Field{Key: "id", Value: id})
+		h.respondError(w, http.StatusRequestEntityTooLarge, "Test payload too large")
+		return
+	}
+
+	result, err := h.hookService.TestHook(r.Context(), id, payload, clientIP)
+	if err != nil {
+		h.respondServiceError(w, r, id, "test", err)
+		return
+	}
+
+	h.log(r).Info("Hook tested successfully",
+		logger.Field{Key: "id", Value: id})
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(result))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/labels"
+)
+
+// parseHookQuery reads the list parameters of GET /api/hooks:
+// sort (a field name, prefixed with "-" for descending order), enabled, name,
+// tag, labels (a label selector such as "env=prod,team=payments"), cursor and limit
+func parseHookQuery(r *http.Request) (domain.HookQuery, error) {
+	values := r.URL.Query()
+	query := domain.HookQuery{
+		Name:   values.Get("name"),
+		Tag:    values.Get("tag"),
+		Cursor: values.Get("cursor"),
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		query.Descending = strings.HasPrefix(sort, "-")
+		query.SortBy = domain.HookSortField(strings.TrimPrefix(sort, "-"))
+		if !query.SortBy.Valid() {
+			return query, fmt.Errorf("sort must be one of id, name, created_at, updated_at")
+		}
+	}
+
+	selector, err := labels.Parse(values.Get("labels"))
+	if err != nil {
+		return query, err
+	}
+	query.Labels = selector
+
+	if enabled := values.Get("enabled"); enabled != "" {
+		b, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return query, fmt.Errorf("enabled must be true or false")
+		}
+		query.Enabled = &b
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return query, fmt.Errorf("limit must be a positive number")
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// nextPageURL returns the request URL with the cursor replaced, keeping all
+// other parameters so the next page uses the same query
+func nextPageURL(r *http.Request, cursor string) string {
+	values := r.URL.Query()
+	values.Set("cursor", cursor)
+
+	// Behind http.StripPrefix r.URL.Path lacks the API prefix; the original
+	// request URI still has it
+	path := r.URL.Path
+	if r.RequestURI != "" {
+		path, _, _ = strings.Cut(r.RequestURI, "?")
+	}
+	return path + "?" + values.Encode()
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// maxImportSize limits the size of an import document
+const maxImportSize = 10 << 20
+
+// exportHooks handles GET /api/hooks/export
+func (h *Handler) exportHooks(w http.ResponseWriter, r *http.Request) {
+	// Authentication is handled by middleware
+
+	omitSecrets, err := parseBoolQuery(r, "omit_secrets")
+	if err != nil {
+		h.log(r).Warn("Invalid omit_secrets parameter",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid omit_secrets parameter")
+		return
+	}
+
+	export, err := h.hookService.ExportHooks(r.Context(), omitSecrets)
+	if err != nil {
+		h.respondServiceError(w, r, "", "export", err)
+		return
+	}
+
+	h.log(r).Info("Hooks exported successfully",
+		logger.Field{Key: "count", Value: len(export.Hooks)},
+		logger.Field{Key: "omit_secrets", Value: omitSecrets})
+
+	// The export is sent as a plain document so it can be posted back to import unchanged
+	w.Header().Set("Content-Disposition", `attachment; filename="hooks-export.json"`)
+	h.respondJSON(w, http.StatusOK, export)
+}
+
+// importHooks handles POST /api/hooks/import?mode=<mode>&dry_run=<bool>.
+// The body is a document in the export format; only its hooks are used.
+func (h *Handler) importHooks(w http.ResponseWriter, r *http.Request) {
+	// Authentication is handled by middleware
+
+	mode := domain.ImportMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = domain.ImportCreateOnly
+	}
+
+	dryRun, err := parseBoolQuery(r, "dry_run")
+	if err != nil {
+		h.log(r).Warn("Invalid dry_run parameter",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid dry_run parameter")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil {
+		h.log(r).Warn("Invalid request body",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(body) > maxImportSize {
+		h.log(r).Warn("Import document too large")
+		h.respondError(w, http.StatusRequestEntityTooLarge, "Import document too large")
+		return
+	}
+
+	var doc domain.HookExport
+	if err := json.Unmarshal(body, &doc); err != nil {
+		h.log(r).Warn("Invalid request body",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.hookService.ImportHooks(r.Context(), doc.Hooks, mode, dryRun)
+	if err != nil {
+		// Report the planned changes along with the conflicting IDs
+		if result != nil && (errors.Is(err, domain.ErrHookExists) || errors.Is(err, domain.ErrHookManaged)) {
+			h.log(r).Warn("Hook import conflicts with existing hooks",
+				logger.Field{Key: "error", Value: err.Error()})
+			message := "Hooks already exist"
+			if errors.Is(err, domain.ErrHookManaged) {
+				message = "Hooks are managed by provisioning"
+			}
+			response := domain.NewErrorResponse(message)
+			response.Data = result
+			h.respondJSON(w, http.StatusConflict, response)
+			return
+		}
+		h.respondServiceError(w, r, "", "import", err)
+		return
+	}
+
+	h.log(r).Info("Hooks imported successfully",
+		logger.Field{Key: "mode", Value: string(mode)},
+		logger.Field{Key: "dry_run", Value: dryRun},
+		logger.Field{Key: "created", Value: len(result.Created)},
+		logger.Field{Key: "updated", Value: len(result.Updated)},
+		logger.Field{Key: "deleted", Value: len(result.Deleted)})
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(result))
+}
+
+// parseBoolQuery parses an optional boolean query parameter, defaulting to false
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
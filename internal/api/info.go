@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/internal/version"
+)
+
+// ServerInfo is the build and runtime information reported by GET /api/info
+type ServerInfo struct {
+	version.Info
+	StartedAt     time.Time   `json:"started_at"`
+	UptimeSeconds int64       `json:"uptime_seconds"`
+	Uptime        string      `json:"uptime"`
+	Hooks         int         `json:"hooks"`
+	Config        interface{} `json:"config,omitempty"`
+}
+
+// SetConfigSummary sets the configuration reported by GET /api/info. Secrets
+// must already be removed from it.
+func (h *Handler) SetConfigSummary(summary interface{}) {
+	h.configSummary = summary
+}
+
+// getInfo handles GET /api/info
+func (h *Handler) getInfo(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.hookService.GetAllHooks(r.Context())
+	if err != nil {
+		h.respondServiceError(w, r, "", "list", err)
+		return
+	}
+
+	uptime := time.Since(h.startTime)
+	info := ServerInfo{
+		Info:          version.Get(),
+		StartedAt:     h.startTime,
+		UptimeSeconds: int64(uptime.Seconds()),
+		Uptime:        uptime.Truncate(time.Second).String(),
+		Hooks:         len(hooks),
+		Config:        h.configSummary,
+	}
+
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(info))
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// maxDebugHooks limits the number of hooks selected for debug logging
+const maxDebugHooks = 100
+
+// LogLevelStatus is the runtime logging configuration reported by GET /api/log/level
+type LogLevelStatus struct {
+	Level      string   `json:"level"`
+	DebugHooks []string `json:"debug_hooks"`
+}
+
+// logLevelRequest is the body of PUT /api/log/level. Omitted fields are left
+// unchanged; an empty debug_hooks list turns per-hook debug logging off.
+type logLevelRequest struct {
+	Level      *string   `json:"level"`
+	DebugHooks *[]string `json:"debug_hooks"`
+}
+
+// debugHooks is the set of hook IDs whose webhook requests are dumped
+type debugHooks struct {
+	mu  sync.RWMutex
+	ids map[string]bool
+}
+
+func (d *debugHooks) has(id string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.ids[id]
+}
+
+func (d *debugHooks) set(ids []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ids = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		d.ids[id] = true
+	}
+}
+
+func (d *debugHooks) list() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ids := make([]string, 0, len(d.ids))
+	for id := range d.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetDebugHooks selects the hooks whose webhook requests and responses are
+// logged in full, regardless of the log level
+func (h *Handler) SetDebugHooks(ids []string) {
+	h.debugHooks.set(ids)
+}
+
+// getLogLevel handles GET /api/log/level
+func (h *Handler) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	// Authentication is handled by middleware
+
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(h.logLevelStatus()))
+}
+
+// setLogLevel handles PUT /api/log/level. The change lasts until the next
+// change or restart; it is not written to the configuration file.
+func (h *Handler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	// Authentication is handled by middleware
+
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log(r).Warn("Invalid request body",
+			logger.Field{Key: "error", Value: err.Error()})
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	fields := make(map[string]string)
+	level := h.logger.Level()
+	if req.Level != nil {
+		parsed, err := logger.ParseLevel(*req.Level)
+		if err != nil {
+			fields["level"] = "must be one of debug, info, warn, error, fatal"
+		}
+		level = parsed
+	}
+	if req.DebugHooks != nil {
+		if len(*req.DebugHooks) > maxDebugHooks {
+			fields["debug_hooks"] = fmt.Sprintf("must not contain more than %d hooks", maxDebugHooks)
+		}
+		for i, id := range *req.DebugHooks {
+			if id == "" {
+				fields[fmt.Sprintf("debug_hooks[%d]", i)] = "must not be empty"
+			}
+		}
+	}
+	if len(fields) > 0 {
+		h.log(r).Warn("Invalid log level settings",
+			logger.Field{Key: "fields", Value: fields})
+		h.respondJSON(w, http.StatusUnprocessableEntity, domain.NewValidationErrorResponse("Invalid log level settings", fields))
+		return
+	}
+
+	previous := h.logLevelStatus()
+	h.logger.SetLevel(level)
+	if req.DebugHooks != nil {
+		h.debugHooks.set(*req.DebugHooks)
+	}
+	status := h.logLevelStatus()
+
+	// Logged at info regardless of the new level, so the change is always recorded
+	h.log(r).WithLevel(logger.InfoLevel).Info("Log level changed",
+		logger.Field{Key: "previous_log_level", Value: previous.Level},
+		logger.Field{Key: "log_level", Value: status.Level},
+		logger.Field{Key: "debug_hooks", Value: status.DebugHooks})
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(status))
+}
+
+// logLevelStatus returns the current log level and debug hooks
+func (h *Handler) logLevelStatus() LogLevelStatus {
+	return LogLevelStatus{
+		Level:      h.logger.Level().String(),
+		DebugHooks: h.debugHooks.list(),
+	}
+}
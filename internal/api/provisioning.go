@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// getProvisioningDrift handles GET /api/provisioning. It compares managed
+// hooks with their definitions without changing anything.
+func (h *Handler) getProvisioningDrift(w http.ResponseWriter, r *http.Request) {
+	h.provision(w, r, true)
+}
+
+// reconcileProvisioning handles POST /api/provisioning/reconcile
+func (h *Handler) reconcileProvisioning(w http.ResponseWriter, r *http.Request) {
+	h.provision(w, r, false)
+}
+
+// provision runs the provisioner and responds with its report
+func (h *Handler) provision(w http.ResponseWriter, r *http.Request, dryRun bool) {
+	// Authentication is handled by middleware
+
+	if h.provisioner == nil {
+		h.log(r).Warn("Provisioning is not configured")
+		h.respondError(w, http.StatusNotFound, "Provisioning is not configured")
+		return
+	}
+
+	report, err := h.provisioner.Reconcile(r.Context(), dryRun)
+	if err != nil {
+		h.respondServiceError(w, r, "", "provision", err)
+		return
+	}
+
+	h.log(r).Info("Provisioning completed successfully",
+		logger.Field{Key: "dry_run", Value: dryRun},
+		logger.Field{Key: "in_sync", Value: report.InSync()})
+	h.respondJSON(w, http.StatusOK, domain.NewSuccessResponse(report))
+}
@@ -9,32 +9,80 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Server ServerConfig `json:"server"`
-	Hooks  HooksConfig  `json:"hooks"`
-	Log    LogConfig    `json:"log"`
+	Server  ServerConfig  `json:"server"`
+	Hooks   HooksConfig   `json:"hooks"`
+	Log     LogConfig     `json:"log"`
+	Metrics MetricsConfig `json:"metrics"`
+	Health  HealthConfig  `json:"health"`
 }
 
 // ServerConfig contains HTTP server configuration
 type ServerConfig struct {
-	Host       string `json:"host"`
-	Port       int    `json:"port"`
-	BasePath   string `json:"base_path"`   // Base path for all routes, e.g. "/hooks" when proxied behind nginx
-	AdminToken string `json:"admin_token"` // Admin token for managing hooks
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	BasePath       string `json:"base_path"`        // Base path for all routes, e.g. "/hooks" when proxied behind nginx
+	AdminToken     string `json:"admin_token"`      // Admin token for managing hooks
+	RequireIfMatch bool   `json:"require_if_match"` // Reject PUT, PATCH and DELETE of hooks without an If-Match header
 }
 
 // HooksConfig contains webhook configuration
 type HooksConfig struct {
-	StoragePath string `json:"storage_path"`
-	FlagsDir    string `json:"flags_dir"`
+	StorageDriver  string `json:"storage_driver"` // Storage backend (json, bolt, sqlite, postgres)
+	StoragePath    string `json:"storage_path"`   // Path to the JSON hooks file; imported once into other backends
+	DatabasePath   string `json:"database_path"`  // Path to the database file for the bolt backend
+	StorageDSN     string `json:"storage_dsn"`    // Data source name for the sqlite and postgres backends
+	FlagsDir       string `json:"flags_dir"`
+	ReloadInterval int    `json:"reload_interval"` // Seconds between checks of the hooks file for external changes (0 disables)
+	ProvisionDir   string `json:"provision_dir"`   // Directory of hook definition files reconciled at startup and on SIGHUP (empty disables)
 }
 
 // LogConfig contains logging configuration
 type LogConfig struct {
-	Level      string `json:"level"`       // Log level (debug, info, warn, error, fatal)
-	Format     string `json:"format"`      // Log format (json, text)
-	FilePath   string `json:"file_path"`   // Path to log file (if empty, logs to stdout)
-	MaxSize    int64  `json:"max_size"`    // Maximum size of log file in MB before rotation
-	MaxBackups int    `json:"max_backups"` // Maximum number of old log files to retain
+	Level           string          `json:"level"`            // Log level (debug, info, warn, error, fatal)
+	Format          string          `json:"format"`           // Log format (json, text, logfmt)
+	FilePath        string          `json:"file_path"`        // Path to log file (if empty, logs to stdout)
+	MaxSize         int64           `json:"max_size"`         // Maximum size of log file in MB before rotation
+	MaxBackups      int             `json:"max_backups"`      // Maximum number of old log files to retain
+	MaxAgeDays      int             `json:"max_age_days"`     // Remove old log files after this many days (0 keeps them)
+	Compress        bool            `json:"compress"`         // Gzip old log files
+	RotateEvery     string          `json:"rotate_every"`     // Also rotate "daily" or "hourly" (if empty, by size only)
+	TimestampFormat string          `json:"timestamp_format"` // Timestamp format (rfc3339, rfc3339nano)
+	Sinks           []LogSinkConfig `json:"sinks"`            // Log destinations (if empty, file_path or stdout)
+	Tag             string          `json:"tag"`              // Program name in syslog and journald entries
+	Redact          LogRedactConfig `json:"redact"`           // Secrets removed from log entries in addition to the built-in ones
+	DebugHooks      []string        `json:"debug_hooks"`      // Hook IDs whose webhook requests are logged in full
+}
+
+// LogSinkConfig configures one log destination
+type LogSinkConfig struct {
+	Type        string `json:"type"`         // Sink type (file, stdout, stderr, syslog, journald)
+	Level       string `json:"level"`        // Minimum log level of this sink (if empty, the log level)
+	Format      string `json:"format"`       // Log format of this sink (if empty, the log format)
+	FilePath    string `json:"file_path"`    // Path to log file (file sinks)
+	MaxSize     int64  `json:"max_size"`     // Maximum size of log file in MB before rotation (file sinks)
+	MaxBackups  int    `json:"max_backups"`  // Maximum number of old log files to retain (file sinks)
+	MaxAgeDays  int    `json:"max_age_days"` // Remove old log files after this many days (file sinks)
+	Compress    bool   `json:"compress"`     // Gzip old log files (file sinks)
+	RotateEvery string `json:"rotate_every"` // Also rotate "daily" or "hourly" (file sinks)
+	Address     string `json:"address"`      // Unix socket path (syslog and journald sinks)
+	Facility    string `json:"facility"`     // Syslog facility (syslog sinks)
+}
+
+// LogRedactConfig contains additional secrets to remove from log entries
+type LogRedactConfig struct {
+	Keys     []string `json:"keys"`     // Field names whose values are replaced
+	Patterns []string `json:"patterns"` // Regular expressions whose first group (or whole match) is replaced
+}
+
+// MetricsConfig contains Prometheus metrics configuration
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"` // Serve metrics at <base_path>/metrics
+	Token   string `json:"token"`   // Bearer token required to read metrics (if empty, no authentication)
+}
+
+// HealthConfig contains readiness check configuration
+type HealthConfig struct {
+	MinFreeDiskMB int `json:"min_free_disk_mb"` // Free space required on the flags and storage file systems (0 disables the check)
 }
 
 // LoadConfig loads configuration from file
@@ -42,21 +90,45 @@ func LoadConfig(path string) (*Config, error) {
 	// Default configuration
 	cfg := &Config{
 		Server: ServerConfig{
-			Host:       "127.0.0.1",
-			Port:       8080,
-			BasePath:   "", // Empty string means no base path (server at root)
-			AdminToken: "", // Default admin token, should be changed in production
+			Host:           "127.0.0.1",
+			Port:           8080,
+			BasePath:       "",    // Empty string means no base path (server at root)
+			AdminToken:     "",    // Default admin token, should be changed in production
+			RequireIfMatch: false, // If-Match is optional
 		},
 		Hooks: HooksConfig{
-			StoragePath: "data/hooks.json",
-			FlagsDir:    "data/flags",
+			StorageDriver:  "json",
+			StoragePath:    "data/hooks.json",
+			DatabasePath:   "data/hooks.db",
+			StorageDSN:     "",
+			FlagsDir:       "data/flags",
+			ReloadInterval: 5,  // Check for external changes every 5 seconds
+			ProvisionDir:   "", // Provisioning disabled
 		},
 		Log: LogConfig{
-			Level:      "info",
-			Format:     "json",
-			FilePath:   "",  // Default to stdout
-			MaxSize:    100, // 100 MB
-			MaxBackups: 5,   // Keep 5 old log files
+			Level:           "info",
+			Format:          "json",
+			FilePath:        "",  // Default to stdout
+			MaxSize:         100, // 100 MB
+			MaxBackups:      5,   // Keep 5 old log files
+			MaxAgeDays:      0,   // No age limit
+			Compress:        false,
+			RotateEvery:     "", // Rotate by size only
+			TimestampFormat: "rfc3339",
+			Sinks:           []LogSinkConfig{},
+			Tag:             "webhook-forge",
+			Redact: LogRedactConfig{
+				Keys:     []string{},
+				Patterns: []string{},
+			},
+			DebugHooks: []string{},
+		},
+		Metrics: MetricsConfig{
+			Enabled: false,
+			Token:   "",
+		},
+		Health: HealthConfig{
+			MinFreeDiskMB: 100,
 		},
 	}
 
@@ -116,3 +188,24 @@ func (c *Config) Save(path string) error {
 
 	return nil
 }
+
+// redacted replaces a secret setting; unset secrets stay empty so that it is
+// still visible whether one is configured
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with the admin token, the
+// metrics token and the storage DSN, which may hold a database password,
+// replaced by [REDACTED]
+func (c *Config) Redacted() Config {
+	redactedCfg := *c
+	if redactedCfg.Server.AdminToken != "" {
+		redactedCfg.Server.AdminToken = redacted
+	}
+	if redactedCfg.Metrics.Token != "" {
+		redactedCfg.Metrics.Token = redacted
+	}
+	if redactedCfg.Hooks.StorageDSN != "" {
+		redactedCfg.Hooks.StorageDSN = redacted
+	}
+	return redactedCfg
+}
@@ -1,46 +1,156 @@
 package domain
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Common errors
 var (
 	ErrHookNotFound      = errors.New("hook not found")
+	ErrHookExists        = errors.New("hook already exists")
 	ErrInvalidToken      = errors.New("invalid token")
 	ErrInvalidHookConfig = errors.New("invalid hook configuration")
+	ErrRevisionMismatch  = errors.New("hook revision mismatch")
+	ErrInvalidPatch      = errors.New("invalid hook patch")
+	ErrHookManaged       = errors.New("hook is managed by provisioning")
+	ErrHookDisabled      = errors.New("hook is disabled")
 )
 
+// ValidationError is returned when a hook fails validation.
+// Fields maps each invalid field name to a description of the problem.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+// NewValidationError creates a validation error from field errors
+func NewValidationError(fields map[string]string) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %s", k, e.Fields[k]))
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidHookConfig) to match validation errors
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidHookConfig
+}
+
 // Hook represents a webhook configuration
 type Hook struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Token       string    `json:"token"`
-	FlagFile    string    `json:"flag_file"`
-	Enabled     bool      `json:"enabled"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Token       string            `json:"token"`
+	FlagFile    string            `json:"flag_file"`
+	Enabled     bool              `json:"enabled"`
+	Tags        []string          `json:"tags,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"` // Free-form key/value metadata, e.g. env=prod
+	Revision    int64             `json:"revision"`         // Incremented on every successful update
+	Managed     bool              `json:"managed"`          // Owned by the provisioner; read-only through the API
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// HasTag reports whether the hook carries tag
+func (h *Hook) HasTag(tag string) bool {
+	for _, t := range h.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether two hooks have the same content, including
+// server-managed fields. Nil and empty collections are considered equal.
+func (h *Hook) Equal(other *Hook) bool {
+	return h.ID == other.ID &&
+		h.Name == other.Name &&
+		h.Description == other.Description &&
+		h.Token == other.Token &&
+		h.FlagFile == other.FlagFile &&
+		h.Enabled == other.Enabled &&
+		slices.Equal(h.Tags, other.Tags) &&
+		maps.Equal(h.Labels, other.Labels) &&
+		h.Revision == other.Revision &&
+		h.Managed == other.Managed &&
+		h.CreatedAt.Equal(other.CreatedAt) &&
+		h.UpdatedAt.Equal(other.UpdatedAt)
+}
+
+// TriggerResult describes the outcome of running a hook's actions
+type TriggerResult struct {
+	HookID      string            `json:"hook_id"`
+	FlagFile    string            `json:"flag_file"`
+	TriggeredAt time.Time         `json:"triggered_at"`
+	Test        bool              `json:"test"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	PayloadSize int               `json:"payload_size"`
+	FlagContent string            `json:"flag_content,omitempty"` // Content written to the flag file by a test
 }
 
 // HookRepository defines the interface for hook storage
 type HookRepository interface {
 	GetByID(id string) (*Hook, error)
 	GetAll() ([]*Hook, error)
+	// List returns the hooks matching a query, in a stable order
+	List(query HookQuery) (*HookPage, error)
 	Create(hook *Hook) error
+	// Update replaces an existing hook. If hook.Revision is non-zero it must
+	// match the stored revision, otherwise ErrRevisionMismatch is returned.
 	Update(hook *Hook) error
-	Delete(id string) error
+	// Delete removes a hook. A non-zero revision must match the stored one.
+	Delete(id string, revision int64) error
+	// Apply performs all changes of a batch or none of them. The same rules as
+	// for Create, Update and Delete apply to each hook in the batch.
+	Apply(batch *HookBatch) error
+}
+
+// HookBatch is a set of changes applied atomically by HookRepository.Apply.
+// Deleted hooks are identified by ID and, if non-zero, the expected revision.
+type HookBatch struct {
+	Create []*Hook
+	Update []*Hook
+	Delete []*Hook
 }
 
 // HookService defines the interface for hook business logic
 type HookService interface {
-	GetHook(id string) (*Hook, error)
-	GetAllHooks() ([]*Hook, error)
-	CreateHook(hook *Hook) error
-	UpdateHook(hook *Hook) error
-	DeleteHook(id string) error
-	ValidateHookToken(id string, token string) error
-	TriggerHook(id string, token string, clientIP string) error
+	GetHook(ctx context.Context, id string) (*Hook, error)
+	GetAllHooks(ctx context.Context) ([]*Hook, error)
+	ListHooks(ctx context.Context, query HookQuery) (*HookPage, error)
+	CreateHook(ctx context.Context, hook *Hook) error
+	UpdateHook(ctx context.Context, hook *Hook) error
+	// PatchHook applies a patch function to the JSON form of the stored hook
+	PatchHook(ctx context.Context, id string, revision int64, apply func(doc []byte) ([]byte, error)) (*Hook, error)
+	DeleteHook(ctx context.Context, id string, revision int64) error
+	SetHookEnabled(ctx context.Context, id string, enabled bool, revision int64) (*Hook, error)
+	RegenerateHookToken(ctx context.Context, id string, revision int64) (*Hook, error)
+	TestHook(ctx context.Context, id string, payload []byte, clientIP string) (*TriggerResult, error)
+	ExportHooks(ctx context.Context, omitSecrets bool) (*HookExport, error)
+	// ImportHooks applies a set of hook definitions in a single batch. With
+	// dryRun set, it only reports the planned changes.
+	ImportHooks(ctx context.Context, hooks []*Hook, mode ImportMode, dryRun bool) (*ImportResult, error)
+	ValidateHookToken(ctx context.Context, id string, token string) error
+	TriggerHook(ctx context.Context, id string, token string, clientIP string) error
 	GenerateToken() string
 }
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// ImportMode controls how imported hooks are merged with existing ones
+type ImportMode string
+
+// Supported import modes
+const (
+	// ImportCreateOnly creates new hooks and fails if any ID already exists
+	ImportCreateOnly ImportMode = "create-only"
+	// ImportUpsert creates new hooks and replaces existing ones
+	ImportUpsert ImportMode = "upsert"
+	// ImportReplaceAll makes the stored hooks match the import exactly,
+	// deleting hooks that are not part of it
+	ImportReplaceAll ImportMode = "replace-all"
+)
+
+// Valid reports whether m is a supported import mode
+func (m ImportMode) Valid() bool {
+	switch m {
+	case ImportCreateOnly, ImportUpsert, ImportReplaceAll:
+		return true
+	}
+	return false
+}
+
+// HookExport is a portable document of hook definitions, accepted back by import
+type HookExport struct {
+	Version     int       `json:"version"`
+	ExportedAt  time.Time `json:"exported_at"`
+	OmitSecrets bool      `json:"omit_secrets"`
+	Hooks       []*Hook   `json:"hooks"`
+}
+
+// ImportResult lists the hook IDs affected by an import, or that would be
+// affected in a dry run
+type ImportResult struct {
+	Mode      ImportMode `json:"mode"`
+	DryRun    bool       `json:"dry_run"`
+	Created   []string   `json:"created"`
+	Updated   []string   `json:"updated"`
+	Deleted   []string   `json:"deleted"`
+	Unchanged []string   `json:"unchanged"`
+	Conflicts []string   `json:"conflicts,omitempty"` // Existing IDs rejected in create-only mode
+}
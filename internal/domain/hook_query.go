@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+
+	"webhook-forge/pkg/labels"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor that is malformed or
+// was issued for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// HookSortField is a field hooks can be ordered by
+type HookSortField string
+
+// Supported sort fields
+const (
+	SortByID        HookSortField = "id"
+	SortByName      HookSortField = "name"
+	SortByCreatedAt HookSortField = "created_at"
+	SortByUpdatedAt HookSortField = "updated_at"
+)
+
+// Valid reports whether f is a supported sort field
+func (f HookSortField) Valid() bool {
+	switch f {
+	case SortByID, SortByName, SortByCreatedAt, SortByUpdatedAt:
+		return true
+	}
+	return false
+}
+
+// HookQuery selects, orders and pages hooks. Hooks with equal sort values are
+// ordered by ID, so the order is always stable.
+type HookQuery struct {
+	SortBy     HookSortField // Defaults to SortByID
+	Descending bool
+
+	Enabled *bool           // Only hooks with this state, if set
+	Name    string          // Case-insensitive substring of the name
+	Tag     string          // Only hooks carrying this tag
+	Labels  labels.Selector // Only hooks whose labels match
+
+	Cursor string // Continue after the last hook of a previous page
+	Limit  int    // Maximum number of hooks; 0 returns all remaining hooks
+}
+
+// HookPage is one page of a hook query
+type HookPage struct {
+	Hooks []*Hook
+	// NextCursor continues the query after this page; empty on the last page
+	NextCursor string
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ProvisionReport describes a reconciliation of declared hook definitions
+// with the stored hooks. In a dry run it shows the drift between the two.
+type ProvisionReport struct {
+	Directory    string    `json:"directory"`
+	DryRun       bool      `json:"dry_run"`
+	ReconciledAt time.Time `json:"reconciled_at"`
+	Created      []string  `json:"created"`   // Declared hooks missing from the store
+	Updated      []string  `json:"updated"`   // Managed hooks that differ from their definition
+	Deleted      []string  `json:"deleted"`   // Managed hooks without a definition
+	Unchanged    []string  `json:"unchanged"` // Managed hooks matching their definition
+	Conflicts    []string  `json:"conflicts"` // Declared IDs taken by hooks not managed by provisioning
+}
+
+// InSync reports whether the stored hooks match the definitions
+func (r *ProvisionReport) InSync() bool {
+	return len(r.Created) == 0 && len(r.Updated) == 0 && len(r.Deleted) == 0 && len(r.Conflicts) == 0
+}
+
+// HookProvisioner reconciles stored hooks with declarative definitions
+type HookProvisioner interface {
+	// Reconcile brings managed hooks in line with their definitions. With
+	// dryRun set, it only reports the drift.
+	Reconcile(ctx context.Context, dryRun bool) (*ProvisionReport, error)
+}
@@ -2,9 +2,16 @@ package domain
 
 // APIResponse represents a standard API response structure
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Errors  []string    `json:"errors,omitempty"`
+	Success bool              `json:"success"`
+	Data    interface{}       `json:"data,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"` // Field-level validation errors
+
+	// NextCursor continues a paginated list; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// RequestID identifies the request in logs and flag files
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // NewSuccessResponse creates a new success response
@@ -24,3 +31,13 @@ func NewErrorResponse(errors ...string) APIResponse {
 		Errors:  errors,
 	}
 }
+
+// NewValidationErrorResponse creates a new error response with field-level errors
+func NewValidationErrorResponse(message string, fields map[string]string) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    nil,
+		Errors:  []string{message},
+		Fields:  fields,
+	}
+}
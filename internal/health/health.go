@@ -0,0 +1,168 @@
+// Package health runs the readiness checks behind the health endpoints.
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of checks and reports
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout limits how long a run of all checks may take
+const DefaultTimeout = 3 * time.Second
+
+// CheckFunc checks one component, returning an error if it is unusable
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of all checks. Status is down if any check failed.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Failed returns the names of the failed checks in sorted order
+func (r Report) Failed() []string {
+	var failed []string
+	for name, result := range r.Checks {
+		if result.Status != StatusUp {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// namedCheck is a registered check
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs registered checks
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []namedCheck
+}
+
+// NewChecker creates a checker whose runs are limited to timeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check under name
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs all checks concurrently. A check that has not finished when the
+// timeout expires is reported as down.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := runCheck(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+// runCheck runs one check, giving up when ctx is done
+func runCheck(ctx context.Context, check CheckFunc) CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out: %w", ctx.Err())
+	}
+
+	result := CheckResult{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// WritableDir checks that files can be created in dir by writing and removing
+// a probe file. The directory is created if it does not exist, as it would be
+// on the first write.
+func WritableDir(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		file, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return fmt.Errorf("failed to create probe file: %w", err)
+		}
+		defer os.Remove(file.Name())
+
+		if _, err := file.WriteString("ok"); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write probe file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write probe file: %w", err)
+		}
+		return os.Remove(file.Name())
+	}
+}
+
+// DiskSpace checks that the file system holding dir has at least minFree
+// bytes available
+func DiskSpace(dir string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(dir)
+		if err != nil {
+			return fmt.Errorf("failed to get free disk space: %w", err)
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MB free, below the minimum of %d MB", free>>20, minFree>>20)
+		}
+		return nil
+	}
+}
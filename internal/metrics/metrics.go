@@ -0,0 +1,91 @@
+// Package metrics defines the metrics exported by webhook-forge. A nil
+// *Metrics is valid and records nothing.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"webhook-forge/pkg/metrics"
+)
+
+// Hook trigger results
+const (
+	TriggerSuccess  = "success"
+	TriggerFailure  = "failure"
+	TriggerRejected = "rejected"
+)
+
+// Metrics records HTTP, hook and authentication metrics
+type Metrics struct {
+	registry *metrics.Registry
+
+	httpRequests      *metrics.Counter
+	httpDuration      *metrics.Histogram
+	hookTriggers      *metrics.Counter
+	authFailures      *metrics.Counter
+	flagWriteDuration *metrics.Histogram
+}
+
+// New creates the metrics in a new registry
+func New() *Metrics {
+	r := metrics.NewRegistry()
+	return &Metrics{
+		registry: r,
+		httpRequests: r.NewCounter("webhook_forge_http_requests_total",
+			"HTTP requests by method, route and status code.",
+			"method", "route", "status"),
+		httpDuration: r.NewHistogram("webhook_forge_http_request_duration_seconds",
+			"HTTP request latency by method, route and status code.",
+			metrics.DefaultBuckets, "method", "route", "status"),
+		hookTriggers: r.NewCounter("webhook_forge_hook_triggers_total",
+			"Hook triggers by hook ID and result (success, failure, rejected).",
+			"hook", "result"),
+		authFailures: r.NewCounter("webhook_forge_auth_failures_total",
+			"Authentication failures by scope (admin, webhook, metrics) and reason.",
+			"scope", "reason"),
+		flagWriteDuration: r.NewHistogram("webhook_forge_flag_write_duration_seconds",
+			"Time taken to write flag files.",
+			[]float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}),
+	}
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return m.registry
+}
+
+// ObserveRequest records a completed HTTP request
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.Inc(method, route, code)
+	m.httpDuration.Observe(duration.Seconds(), method, route, code)
+}
+
+// HookTriggered records the result of triggering a hook
+func (m *Metrics) HookTriggered(hookID, result string) {
+	if m == nil {
+		return
+	}
+	m.hookTriggers.Inc(hookID, result)
+}
+
+// AuthFailed records a rejected authentication attempt
+func (m *Metrics) AuthFailed(scope, reason string) {
+	if m == nil {
+		return
+	}
+	m.authFailures.Inc(scope, reason)
+}
+
+// ObserveFlagWrite records the time taken to write a flag file
+func (m *Metrics) ObserveFlagWrite(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.flagWriteDuration.Observe(duration.Seconds())
+}
@@ -5,51 +5,84 @@ import (
 	"strings"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/metrics"
 	"webhook-forge/pkg/logger"
 )
 
+// Reasons for rejected bearer token authentication, recorded in metrics
+const (
+	reasonMissingToken    = "missing_token"
+	reasonMalformedHeader = "malformed_header"
+	reasonInvalidToken    = "invalid_token"
+)
+
 // AdminAuth provides middleware for admin API endpoints authentication
 type AdminAuth struct {
 	logger     logger.Logger
 	adminToken string
+	metrics    *metrics.Metrics
+	scope      string // scope of auth failures in metrics
 }
 
 // NewAdminAuth creates a new admin authentication middleware
-func NewAdminAuth(logger logger.Logger, adminToken string) domain.AdminAuthMiddleware {
+func NewAdminAuth(logger logger.Logger, adminToken string, m *metrics.Metrics) domain.AdminAuthMiddleware {
 	return &AdminAuth{
 		logger:     logger,
 		adminToken: adminToken,
+		metrics:    m,
+		scope:      "admin",
+	}
+}
+
+// NewMetricsAuth creates a middleware that protects the metrics endpoint with
+// its own bearer token, so scrapers do not need the admin token
+func NewMetricsAuth(logger logger.Logger, metricsToken string, m *metrics.Metrics) domain.AuthenticationMiddleware {
+	return &AdminAuth{
+		logger:     logger,
+		adminToken: metricsToken,
+		metrics:    m,
+		scope:      "metrics",
 	}
 }
 
 // IsAuthenticated checks if the request has a valid admin token
 func (m *AdminAuth) IsAuthenticated(r *http.Request) bool {
+	return m.check(r) == ""
+}
+
+// check returns why the request is not authenticated, or "" if it is
+func (m *AdminAuth) check(r *http.Request) string {
 	// Extract the token from the Authorization header
 	authHeader := r.Header.Get("Authorization")
 
 	// Check if the header exists and has the correct format
 	if authHeader == "" {
-		return false
+		return reasonMissingToken
 	}
 
 	// Expected format: "Bearer <token>"
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		return false
+		return reasonMalformedHeader
 	}
 
 	// Check if the token is valid
 	token := parts[1]
-	return token == m.adminToken
+	if token != m.adminToken {
+		return reasonInvalidToken
+	}
+	return ""
 }
 
 // Middleware returns an http.Handler middleware function for admin authentication
 func (m *AdminAuth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check if the request is authenticated
-		if !m.IsAuthenticated(r) {
-			m.logger.Warn("Authentication failed",
-				logger.Field{Key: "path", Value: r.URL.Path})
+		if reason := m.check(r); reason != "" {
+			m.metrics.AuthFailed(m.scope, reason)
+			m.logger.WithContext(r.Context()).Warn("Authentication failed",
+				logger.Field{Key: "path", Value: r.URL.Path},
+				logger.Field{Key: "reason", Value: reason})
 			http.Error(w, "Admin authentication required", http.StatusForbidden)
 			return
 		}
@@ -1,17 +1,30 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/metrics"
 	"webhook-forge/pkg/logger"
 )
 
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern restricts request IDs accepted from clients to characters
+// that are safe in log lines, headers and flag files
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:+=/-]{1,128}$`)
+
 // RequestLogger is a middleware that logs all incoming requests with IP address information
 type RequestLogger struct {
-	logger logger.Logger
+	logger     logger.Logger
+	metrics    *metrics.Metrics
+	quietPaths []string
 }
 
 // responseWriter is a wrapper for http.ResponseWriter that captures status code and response size
@@ -47,11 +60,25 @@ func (rw *responseWriter) Size() int {
 	return rw.size
 }
 
-// NewRequestLogger creates a new request logger middleware
-func NewRequestLogger(logger logger.Logger) domain.Middleware {
+// NewRequestLogger creates a new request logger middleware that also records
+// request counts and latencies in m. Successful requests to paths starting
+// with one of quietPaths, such as health probes, are logged at debug level.
+func NewRequestLogger(logger logger.Logger, m *metrics.Metrics, quietPaths ...string) domain.Middleware {
 	return &RequestLogger{
-		logger: logger,
+		logger:     logger,
+		metrics:    m,
+		quietPaths: quietPaths,
+	}
+}
+
+// quiet reports whether successful requests to path are logged at debug level
+func (m *RequestLogger) quiet(path string) bool {
+	for _, prefix := range m.quietPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
 	}
+	return false
 }
 
 // getClientIP extracts the client IP address from the request
@@ -84,12 +111,41 @@ func getClientIP(r *http.Request) string {
 	return ip
 }
 
-// Middleware returns an http.Handler middleware function
+// requestID returns the request ID sent by the client, typically by a proxy
+// in front of the server, or a new random ID if none or an invalid one was sent
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); requestIDPattern.MatchString(id) {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to the time, which is unique enough to correlate log lines
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Middleware returns an http.Handler middleware function. It assigns each
+// request an ID, echoes it in the X-Request-ID response header and attaches
+// it to the request context, so handlers and services log it with every entry.
 func (m *RequestLogger) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		clientIP := getClientIP(r)
 
+		// Attach the request ID and client IP to the context, so every entry
+		// logged while serving the request carries them
+		id := requestID(r)
+		w.Header().Set(RequestIDHeader, id)
+		ctx := domain.WithRequestID(r.Context(), id)
+		ctx = logger.ContextWithFields(ctx,
+			logger.Field{Key: "request_id", Value: id},
+			logger.Field{Key: "ip", Value: clientIP})
+		log := m.logger.WithContext(ctx)
+		ctx = logger.NewContext(ctx, m.logger)
+		r = r.WithContext(ctx)
+
 		// Create response writer wrapper
 		rw := &responseWriter{
 			ResponseWriter: w,
@@ -97,44 +153,47 @@ func (m *RequestLogger) Middleware(next http.Handler) http.Handler {
 			size:           0,
 		}
 
-		// Log request start
-		m.logger.Info("Request started",
-			logger.Field{Key: "method", Value: r.Method},
-			logger.Field{Key: "path", Value: r.URL.Path},
-			logger.Field{Key: "ip", Value: clientIP})
+		// Log request start. The path and query are logged separately, with
+		// secrets such as webhook tokens removed from the query.
+		fields := []logger.Field{
+			{Key: "method", Value: r.Method},
+			{Key: "path", Value: r.URL.Path},
+		}
+		if r.URL.RawQuery != "" {
+			fields = append(fields, logger.Field{Key: "query", Value: logger.Query(r.URL.Query())})
+		}
+		log = log.WithFields(fields...)
+		quiet := m.quiet(r.URL.Path)
+		if quiet {
+			log.Debug("Request started")
+		} else {
+			log.Info("Request started")
+		}
 
-		// Call the next handler with our wrapped response writer
+		// Call the next handler with our wrapped response writer; the handlers
+		// it reaches record the route of the request
+		r, rt := withRoute(r)
 		next.ServeHTTP(rw, r)
 
 		// Log request completion with status code and response size
 		duration := time.Since(start)
+		m.metrics.ObserveRequest(r.Method, rt.label(), rw.Status(), duration)
 
 		// Use appropriate log level based on status code
 		logMsg := "Request completed"
+		fields = []logger.Field{
+			{Key: "status", Value: rw.Status()},
+			{Key: "size", Value: rw.Size()},
+			{Key: "duration_ms", Value: duration.Milliseconds()},
+		}
 		if rw.Status() >= 500 {
-			m.logger.Error(logMsg,
-				logger.Field{Key: "method", Value: r.Method},
-				logger.Field{Key: "path", Value: r.URL.Path},
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "status", Value: rw.Status()},
-				logger.Field{Key: "size", Value: rw.Size()},
-				logger.Field{Key: "duration_ms", Value: duration.Milliseconds()})
+			log.Error(logMsg, fields...)
 		} else if rw.Status() >= 400 {
-			m.logger.Warn(logMsg,
-				logger.Field{Key: "method", Value: r.Method},
-				logger.Field{Key: "path", Value: r.URL.Path},
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "status", Value: rw.Status()},
-				logger.Field{Key: "size", Value: rw.Size()},
-				logger.Field{Key: "duration_ms", Value: duration.Milliseconds()})
+			log.Warn(logMsg, fields...)
+		} else if quiet {
+			log.Debug(logMsg, fields...)
 		} else {
-			m.logger.Info(logMsg,
-				logger.Field{Key: "method", Value: r.Method},
-				logger.Field{Key: "path", Value: r.URL.Path},
-				logger.Field{Key: "ip", Value: clientIP},
-				logger.Field{Key: "status", Value: rw.Status()},
-				logger.Field{Key: "size", Value: rw.Size()},
-				logger.Field{Key: "duration_ms", Value: duration.Milliseconds()})
+			log.Info(logMsg, fields...)
 		}
 	})
 }
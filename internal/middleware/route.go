@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// routeKey is the context key of the route recorded for a request
+type routeKey struct{}
+
+// route collects the route of a request as it passes through nested muxes
+type route struct {
+	pattern string
+}
+
+// withRoute returns a request carrying an empty route to be filled by Route
+func withRoute(r *http.Request) (*http.Request, *route) {
+	rt := &route{}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, rt)), rt
+}
+
+// Route returns a handler that appends pattern to the route of the request
+// before calling next. Routes label request metrics, so they use patterns such
+// as "/api" + "/hooks/{id}" rather than raw paths. A method in the pattern
+// ("GET /hooks") is left out since the method is recorded separately.
+func Route(pattern string, next http.Handler) http.Handler {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+			rt.pattern += pattern
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// label returns the recorded route, or "unmatched" for requests no route handled
+func (rt *route) label() string {
+	if rt.pattern == "" {
+		return "unmatched"
+	}
+	return rt.pattern
+}
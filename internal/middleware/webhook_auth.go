@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/metrics"
 	"webhook-forge/pkg/logger"
 )
 
@@ -12,13 +13,15 @@ import (
 type WebhookAuth struct {
 	logger      logger.Logger
 	hookService domain.HookService
+	metrics     *metrics.Metrics
 }
 
 // NewWebhookAuth creates a new webhook authentication middleware
-func NewWebhookAuth(logger logger.Logger, hookService domain.HookService) domain.WebhookAuthMiddleware {
+func NewWebhookAuth(logger logger.Logger, hookService domain.HookService, m *metrics.Metrics) domain.WebhookAuthMiddleware {
 	return &WebhookAuth{
 		logger:      logger,
 		hookService: hookService,
+		metrics:     m,
 	}
 }
 
@@ -37,7 +40,7 @@ func (m *WebhookAuth) IsAuthenticated(r *http.Request) bool {
 	}
 
 	// Validate hook token
-	if err := m.hookService.ValidateHookToken(id, token); err != nil {
+	if err := m.hookService.ValidateHookToken(r.Context(), id, token); err != nil {
 		return false
 	}
 
@@ -47,10 +50,13 @@ func (m *WebhookAuth) IsAuthenticated(r *http.Request) bool {
 // Middleware returns an http.Handler middleware function for webhook authentication
 func (m *WebhookAuth) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := m.logger.WithContext(r.Context())
+
 		// Extract the hook ID from the URL path
 		id := m.GetHookID(r)
 		if id == "" {
-			m.logger.Warn("Invalid webhook URL format",
+			m.metrics.AuthFailed("webhook", "invalid_url")
+			log.Warn("Invalid webhook URL format",
 				logger.Field{Key: "path", Value: r.URL.Path})
 			http.Error(w, "Invalid webhook URL", http.StatusBadRequest)
 			return
@@ -59,27 +65,37 @@ func (m *WebhookAuth) Middleware(next http.Handler) http.Handler {
 		// Get token from query parameter
 		token := r.URL.Query().Get("token")
 		if token == "" {
-			m.logger.Warn("Missing token parameter",
+			m.metrics.AuthFailed("webhook", reasonMissingToken)
+			log.Warn("Missing token parameter",
 				logger.Field{Key: "id", Value: id})
 			http.Error(w, "Missing token parameter", http.StatusBadRequest)
 			return
 		}
 
 		// Validate hook token
-		if err := m.hookService.ValidateHookToken(id, token); err != nil {
+		if err := m.hookService.ValidateHookToken(r.Context(), id, token); err != nil {
 			if err == domain.ErrHookNotFound {
-				m.logger.Warn("Hook not found",
+				m.metrics.AuthFailed("webhook", "hook_not_found")
+				log.Warn("Hook not found",
 					logger.Field{Key: "id", Value: id})
 				http.Error(w, "Hook not found", http.StatusNotFound)
 				return
 			}
 			if err == domain.ErrInvalidToken {
-				m.logger.Warn("Invalid token",
+				m.metrics.AuthFailed("webhook", reasonInvalidToken)
+				m.metrics.HookTriggered(id, metrics.TriggerRejected)
+				log.Warn("Invalid token",
 					logger.Field{Key: "id", Value: id})
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
-			m.logger.Error("Failed to validate hook token",
+			reason := "error"
+			if err == domain.ErrHookDisabled {
+				reason = "hook_disabled"
+				m.metrics.HookTriggered(id, metrics.TriggerRejected)
+			}
+			m.metrics.AuthFailed("webhook", reason)
+			log.Error("Failed to validate hook token",
 				logger.Field{Key: "id", Value: id},
 				logger.Field{Key: "error", Value: err.Error()})
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
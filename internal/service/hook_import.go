@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// hookExportVersion is the format version of export documents
+const hookExportVersion = 1
+
+// ExportHooks returns all hooks sorted by ID. With omitSecrets set, hook tokens
+// are left out so the export can be shared safely; importing such an export
+// keeps the tokens of existing hooks and generates new ones for new hooks.
+func (s *HookService) ExportHooks(ctx context.Context, omitSecrets bool) (*domain.HookExport, error) {
+	hooks, err := s.repo.GetAll()
+	if err != nil {
+		s.log(ctx).Error("Failed to get hooks for export", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	exported := make([]*domain.Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		// Work on a copy so the stored hook is never modified in place
+		h := *hook
+		if omitSecrets {
+			h.Token = ""
+		}
+		exported = append(exported, &h)
+	}
+	sort.Slice(exported, func(i, j int) bool { return exported[i].ID < exported[j].ID })
+
+	s.log(ctx).Info("Hooks exported",
+		logger.Field{Key: "count", Value: len(exported)},
+		logger.Field{Key: "omit_secrets", Value: omitSecrets})
+
+	return &domain.HookExport{
+		Version:     hookExportVersion,
+		ExportedAt:  time.Now().UTC(),
+		OmitSecrets: omitSecrets,
+		Hooks:       exported,
+	}, nil
+}
+
+// ImportHooks merges hook definitions with the stored hooks according to mode.
+// All hooks are validated and the changes are planned before anything is
+// written; the plan is then applied as one batch, so either every change is
+// made or none is. Server-managed fields (revision, timestamps) in the import
+// are ignored.
+func (s *HookService) ImportHooks(ctx context.Context, hooks []*domain.Hook, mode domain.ImportMode, dryRun bool) (*domain.ImportResult, error) {
+	if !mode.Valid() {
+		return nil, domain.NewValidationError(map[string]string{
+			"mode": fmt.Sprintf("must be one of %s, %s, %s", domain.ImportCreateOnly, domain.ImportUpsert, domain.ImportReplaceAll),
+		})
+	}
+
+	if err := s.validateImport(hooks); err != nil {
+		s.log(ctx).Warn("Failed to validate hook import", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	current, err := s.repo.GetAll()
+	if err != nil {
+		s.log(ctx).Error("Failed to get hooks for import", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	batch, result, managed := s.planImport(current, hooks, mode)
+	result.DryRun = dryRun
+
+	if len(managed) > 0 {
+		s.log(ctx).Warn("Hook import would modify managed hooks", logger.Field{Key: "managed", Value: strings.Join(managed, ",")})
+		result.Conflicts = managed
+		return result, fmt.Errorf("%w: %s", domain.ErrHookManaged, strings.Join(managed, ", "))
+	}
+
+	if len(result.Conflicts) > 0 {
+		s.log(ctx).Warn("Hook import conflicts with existing hooks",
+			logger.Field{Key: "mode", Value: string(mode)},
+			logger.Field{Key: "conflicts", Value: strings.Join(result.Conflicts, ",")})
+		return result, fmt.Errorf("%w: %s", domain.ErrHookExists, strings.Join(result.Conflicts, ", "))
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.repo.Apply(batch); err != nil {
+		s.log(ctx).Error("Failed to import hooks", logger.Field{Key: "mode", Value: string(mode)}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	s.log(ctx).Info("Hooks imported",
+		logger.Field{Key: "mode", Value: string(mode)},
+		logger.Field{Key: "created", Value: len(result.Created)},
+		logger.Field{Key: "updated", Value: len(result.Updated)},
+		logger.Field{Key: "deleted", Value: len(result.Deleted)},
+		logger.Field{Key: "unchanged", Value: len(result.Unchanged)})
+	return result, nil
+}
+
+// validateImport validates every imported hook and reports all problems at
+// once, with field names prefixed by the position of the hook in the import
+func (s *HookService) validateImport(hooks []*domain.Hook) error {
+	fields := make(map[string]string)
+	seen := make(map[string]bool, len(hooks))
+
+	for i, hook := range hooks {
+		prefix := fmt.Sprintf("hooks[%d].", i)
+		if hook == nil {
+			fields[fmt.Sprintf("hooks[%d]", i)] = "must not be null"
+			continue
+		}
+
+		for _, err := range []error{validateHookID(hook.ID), s.validateHook(hook)} {
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) {
+				for field, msg := range validationErr.Fields {
+					fields[prefix+field] = msg
+				}
+			}
+		}
+
+		if hook.ID != "" && seen[hook.ID] {
+			fields[prefix+"id"] = "is duplicated in the import"
+		}
+		seen[hook.ID] = true
+	}
+
+	if len(fields) > 0 {
+		return domain.NewValidationError(fields)
+	}
+	return nil
+}
+
+// planImport compares imported hooks with the current ones and returns the
+// batch that makes the change together with a summary of it, and the IDs of
+// managed hooks the import would change. Written hooks are pinned to the
+// revisions read here, so the batch is rejected if a hook changes before it
+// is applied. Managed hooks are never deleted by an import.
+func (s *HookService) planImport(current, hooks []*domain.Hook, mode domain.ImportMode) (*domain.HookBatch, *domain.ImportResult, []string) {
+	var managed []string
+	batch := &domain.HookBatch{}
+	result := &domain.ImportResult{
+		Mode:      mode,
+		Created:   []string{},
+		Updated:   []string{},
+		Deleted:   []string{},
+		Unchanged: []string{},
+	}
+
+	existing := make(map[string]*domain.Hook, len(current))
+	for _, hook := range current {
+		existing[hook.ID] = hook
+	}
+
+	imported := make(map[string]bool, len(hooks))
+	for _, in := range hooks {
+		imported[in.ID] = true
+
+		// Work on a copy so the caller's hook is never modified
+		hook := *in
+		hook.Tags = normalizeTags(hook.Tags)
+		hook.Labels = normalizeLabels(hook.Labels)
+		old, ok := existing[hook.ID]
+
+		switch {
+		case !ok:
+			// Only the provisioner creates managed hooks
+			hook.Managed = false
+			if hook.Token == "" {
+				hook.Token = s.GenerateToken()
+			}
+			batch.Create = append(batch.Create, &hook)
+			result.Created = append(result.Created, hook.ID)
+
+		case mode == domain.ImportCreateOnly:
+			result.Conflicts = append(result.Conflicts, hook.ID)
+
+		default:
+			// A token left out of the import keeps the current one
+			if hook.Token == "" {
+				hook.Token = old.Token
+			}
+			hook.Revision = old.Revision
+			hook.CreatedAt = old.CreatedAt
+			hook.UpdatedAt = old.UpdatedAt
+			hook.Managed = old.Managed
+
+			if hook.Equal(old) {
+				result.Unchanged = append(result.Unchanged, hook.ID)
+				continue
+			}
+			if old.Managed {
+				managed = append(managed, hook.ID)
+				continue
+			}
+			batch.Update = append(batch.Update, &hook)
+			result.Updated = append(result.Updated, hook.ID)
+		}
+	}
+
+	if mode == domain.ImportReplaceAll {
+		for _, hook := range current {
+			if !imported[hook.ID] && !hook.Managed {
+				batch.Delete = append(batch.Delete, &domain.Hook{ID: hook.ID, Revision: hook.Revision})
+				result.Deleted = append(result.Deleted, hook.ID)
+			}
+		}
+	}
+
+	sort.Strings(result.Created)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Deleted)
+	sort.Strings(result.Unchanged)
+	sort.Strings(result.Conflicts)
+	sort.Strings(managed)
+	return batch, result, managed
+}
@@ -1,119 +1,307 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/internal/metrics"
+	"webhook-forge/pkg/labels"
 	"webhook-forge/pkg/logger"
+	"webhook-forge/pkg/validator"
 )
 
+// Field length limits
+const (
+	maxHookIDLength   = 64
+	maxHookNameLength = 128
+	maxHookTags       = 32
+	maxTagLength      = 64
+	maxHookLabels     = 32
+)
+
+// maxListLimit caps the page size of hook queries
+const maxListLimit = 1000
+
+// reservedHookIDs would clash with fixed API routes under /api/hooks
+var reservedHookIDs = map[string]bool{
+	"export": true,
+	"import": true,
+}
+
 // HookService implements the domain.HookService interface
 type HookService struct {
 	repo     domain.HookRepository
 	flagsDir string
 	logger   logger.Logger
+	metrics  *metrics.Metrics
 }
 
-// NewHookService creates a new HookService
-func NewHookService(repo domain.HookRepository, flagsDir string, logger logger.Logger) *HookService {
+// NewHookService creates a new HookService that records hook triggers in m
+func NewHookService(repo domain.HookRepository, flagsDir string, logger logger.Logger, m *metrics.Metrics) *HookService {
 	return &HookService{
 		repo:     repo,
 		flagsDir: flagsDir,
 		logger:   logger,
+		metrics:  m,
 	}
 }
 
+// log returns the service logger with the fields of ctx, such as the request ID
+func (s *HookService) log(ctx context.Context) logger.Logger {
+	return s.logger.WithContext(ctx)
+}
+
 // GetHook returns a hook by ID
-func (s *HookService) GetHook(id string) (*domain.Hook, error) {
+func (s *HookService) GetHook(ctx context.Context, id string) (*domain.Hook, error) {
 	hook, err := s.repo.GetByID(id)
 	if err != nil {
-		s.logger.Error("Failed to get hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to get hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
 		return nil, err
 	}
 	return hook, nil
 }
 
 // GetAllHooks returns all hooks
-func (s *HookService) GetAllHooks() ([]*domain.Hook, error) {
+func (s *HookService) GetAllHooks(ctx context.Context) ([]*domain.Hook, error) {
 	hooks, err := s.repo.GetAll()
 	if err != nil {
-		s.logger.Error("Failed to get all hooks", logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to get all hooks", logger.Field{Key: "error", Value: err.Error()})
 		return nil, err
 	}
 	return hooks, nil
 }
 
+// ListHooks returns the hooks matching a query, in a stable order
+func (s *HookService) ListHooks(ctx context.Context, query domain.HookQuery) (*domain.HookPage, error) {
+	v := validator.New()
+	v.Check(query.SortBy == "" || query.SortBy.Valid(), "sort", "must be one of id, name, created_at, updated_at")
+	v.Check(query.Limit >= 0 && query.Limit <= maxListLimit, "limit", fmt.Sprintf("must be between 1 and %d", maxListLimit))
+	if !v.Valid() {
+		return nil, domain.NewValidationError(v.GetErrors())
+	}
+
+	page, err := s.repo.List(query)
+	if err != nil {
+		s.log(ctx).Error("Failed to list hooks", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+	return page, nil
+}
+
 // CreateHook creates a new hook
-func (s *HookService) CreateHook(hook *domain.Hook) error {
+func (s *HookService) CreateHook(ctx context.Context, hook *domain.Hook) error {
+	// Only the provisioner creates managed hooks
+	hook.Managed = false
+	hook.Tags = normalizeTags(hook.Tags)
+	hook.Labels = normalizeLabels(hook.Labels)
+
+	// Generate ID if not provided
+	if hook.ID == "" {
+		hook.ID = s.generateHookID(hook.Name)
+	}
+
+	// Validate hook ID; it becomes a URL path segment and a storage key
+	if err := validateHookID(hook.ID); err != nil {
+		s.log(ctx).Error("Failed to validate hook ID", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
+		return err
+	}
+
 	// Validate hook
 	if err := s.validateHook(hook); err != nil {
-		s.logger.Error("Failed to validate hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to validate hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
 	// Create hook
 	if err := s.repo.Create(hook); err != nil {
-		s.logger.Error("Failed to create hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to create hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
-	s.logger.Info("Hook created", logger.Field{Key: "id", Value: hook.ID})
+	s.log(ctx).Info("Hook created", logger.Field{Key: "id", Value: hook.ID})
 	return nil
 }
 
-// UpdateHook updates an existing hook
-func (s *HookService) UpdateHook(hook *domain.Hook) error {
+// UpdateHook updates an existing hook. Hooks managed by the provisioner cannot be updated.
+func (s *HookService) UpdateHook(ctx context.Context, hook *domain.Hook) error {
+	if err := s.checkUnmanaged(ctx, hook.ID); err != nil {
+		return err
+	}
+	hook.Managed = false
+	hook.Tags = normalizeTags(hook.Tags)
+	hook.Labels = normalizeLabels(hook.Labels)
+
 	// Validate hook
 	if err := s.validateHook(hook); err != nil {
-		s.logger.Error("Failed to validate hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to validate hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
 	// Update hook
 	if err := s.repo.Update(hook); err != nil {
-		s.logger.Error("Failed to update hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to update hook", logger.Field{Key: "id", Value: hook.ID}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
-	s.logger.Info("Hook updated", logger.Field{Key: "id", Value: hook.ID})
+	s.log(ctx).Info("Hook updated", logger.Field{Key: "id", Value: hook.ID})
 	return nil
 }
 
-// DeleteHook deletes a hook
-func (s *HookService) DeleteHook(id string) error {
-	if err := s.repo.Delete(id); err != nil {
-		s.logger.Error("Failed to delete hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+// PatchHook applies a patch to the JSON representation of a stored hook and
+// saves the result. Fields managed by the server (id, revision, timestamps,
+// managed) cannot be changed through a patch.
+func (s *HookService) PatchHook(ctx context.Context, id string, revision int64, apply func(doc []byte) ([]byte, error)) (*domain.Hook, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		s.log(ctx).Error("Failed to get hook for patch", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	if revision != 0 && revision != current.Revision {
+		s.log(ctx).Warn("Hook revision mismatch", logger.Field{Key: "id", Value: id}, logger.Field{Key: "revision", Value: revision})
+		return nil, domain.ErrRevisionMismatch
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode hook: %w", err)
+	}
+
+	patched, err := apply(doc)
+	if err != nil {
+		s.log(ctx).Warn("Failed to apply hook patch", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidPatch, err)
+	}
+
+	// Decode into a fresh hook so the stored one is never modified in place
+	var hook domain.Hook
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&hook); err != nil {
+		s.log(ctx).Warn("Patched hook is invalid", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidPatch, err)
+	}
+
+	// Restore server-managed fields
+	hook.ID = current.ID
+	hook.Managed = current.Managed
+	hook.CreatedAt = current.CreatedAt
+	hook.UpdatedAt = current.UpdatedAt
+
+	// Pin the write to the revision the patch was applied to, so a concurrent
+	// update between GetByID and Update is rejected instead of lost
+	hook.Revision = current.Revision
+
+	// A removed token would make the hook impossible to trigger
+	if hook.Token == "" {
+		hook.Token = s.GenerateToken()
+	}
+
+	if err := s.UpdateHook(ctx, &hook); err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// SetHookEnabled enables or disables a hook
+func (s *HookService) SetHookEnabled(ctx context.Context, id string, enabled bool, revision int64) (*domain.Hook, error) {
+	return s.modifyHook(ctx, id, revision, func(hook *domain.Hook) {
+		hook.Enabled = enabled
+	})
+}
+
+// RegenerateHookToken replaces the hook token with a newly generated one
+func (s *HookService) RegenerateHookToken(ctx context.Context, id string, revision int64) (*domain.Hook, error) {
+	return s.modifyHook(ctx, id, revision, func(hook *domain.Hook) {
+		hook.Token = s.GenerateToken()
+	})
+}
+
+// modifyHook applies fn to a copy of the stored hook and saves the result
+func (s *HookService) modifyHook(ctx context.Context, id string, revision int64, fn func(hook *domain.Hook)) (*domain.Hook, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		s.log(ctx).Error("Failed to get hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	if revision != 0 && revision != current.Revision {
+		s.log(ctx).Warn("Hook revision mismatch", logger.Field{Key: "id", Value: id}, logger.Field{Key: "revision", Value: revision})
+		return nil, domain.ErrRevisionMismatch
+	}
+
+	// Work on a copy so the stored hook is never modified in place
+	hook := *current
+	fn(&hook)
+
+	if err := s.UpdateHook(ctx, &hook); err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// DeleteHook deletes a hook. Hooks managed by the provisioner cannot be deleted.
+func (s *HookService) DeleteHook(ctx context.Context, id string, revision int64) error {
+	if err := s.checkUnmanaged(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id, revision); err != nil {
+		s.log(ctx).Error("Failed to delete hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return err
+	}
+
+	s.log(ctx).Info("Hook deleted", logger.Field{Key: "id", Value: id})
+	return nil
+}
+
+// checkUnmanaged returns ErrHookManaged if the hook is owned by the provisioner
+func (s *HookService) checkUnmanaged(ctx context.Context, id string) error {
+	hook, err := s.repo.GetByID(id)
+	if err != nil {
+		s.log(ctx).Error("Failed to get hook", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
-	s.logger.Info("Hook deleted", logger.Field{Key: "id", Value: id})
+	if hook.Managed {
+		s.log(ctx).Warn("Refusing to modify managed hook", logger.Field{Key: "id", Value: id})
+		return domain.ErrHookManaged
+	}
 	return nil
 }
 
 // ValidateHookToken validates a hook token
-func (s *HookService) ValidateHookToken(id string, token string) error {
+func (s *HookService) ValidateHookToken(ctx context.Context, id string, token string) error {
 	hook, err := s.repo.GetByID(id)
 	if err != nil {
-		s.logger.Error("Failed to get hook for token validation", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		s.log(ctx).Error("Failed to get hook for token validation", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
 		return err
 	}
 
 	// Check if hook is enabled
 	if !hook.Enabled {
-		s.logger.Warn("Hook is disabled", logger.Field{Key: "id", Value: id})
-		return fmt.Errorf("hook is disabled")
+		s.log(ctx).Warn("Hook is disabled", logger.Field{Key: "id", Value: id})
+		return domain.ErrHookDisabled
 	}
 
 	// Compare tokens securely
 	if subtle.ConstantTimeCompare([]byte(hook.Token), []byte(token)) != 1 {
-		s.logger.Warn("Invalid token", logger.Field{Key: "id", Value: id})
+		s.log(ctx).Warn("Invalid token", logger.Field{Key: "id", Value: id})
 		return domain.ErrInvalidToken
 	}
 
@@ -121,21 +309,28 @@ func (s *HookService) ValidateHookToken(id string, token string) error {
 }
 
 // TriggerHook triggers a hook
-func (s *HookService) TriggerHook(id string, token string, clientIP string) error {
-	// Validate token
-	if err := s.ValidateHookToken(id, token); err != nil {
+func (s *HookService) TriggerHook(ctx context.Context, id string, token string, clientIP string) error {
+	// Validate token; unknown IDs are not recorded to keep metric labels bounded
+	if err := s.ValidateHookToken(ctx, id, token); err != nil {
+		if err != domain.ErrHookNotFound {
+			s.metrics.HookTriggered(id, metrics.TriggerRejected)
+		}
 		return err
 	}
 
 	// Get hook
 	hook, err := s.repo.GetByID(id)
 	if err != nil {
+		if err != domain.ErrHookNotFound {
+			s.metrics.HookTriggered(id, metrics.TriggerFailure)
+		}
 		return err
 	}
 
 	// Create flag file
-	if err := s.createFlagFile(hook, clientIP); err != nil {
-		s.logger.Error("Failed to create flag file",
+	if _, err := s.createFlagFile(ctx, hook, clientIP, false, nil); err != nil {
+		s.metrics.HookTriggered(id, metrics.TriggerFailure)
+		s.log(ctx).Error("Failed to create flag file",
 			logger.Field{Key: "id", Value: id},
 			logger.Field{Key: "flag_file", Value: hook.FlagFile},
 			logger.Field{Key: "ip", Value: clientIP},
@@ -143,14 +338,56 @@ func (s *HookService) TriggerHook(id string, token string, clientIP string) erro
 		return err
 	}
 
-	s.logger.Info("Hook triggered",
+	s.metrics.HookTriggered(id, metrics.TriggerSuccess)
+	s.log(ctx).Info("Hook triggered",
 		logger.Field{Key: "id", Value: id},
 		logger.Field{Key: "name", Value: hook.Name},
 		logger.Field{Key: "flag_file", Value: hook.FlagFile},
+		logger.Field{Key: "labels", Value: hook.Labels},
 		logger.Field{Key: "ip", Value: clientIP})
 	return nil
 }
 
+// TestHook runs the hook's actions without token validation so operators can
+// verify a hook end to end. Disabled hooks can be tested as well. The flag file
+// records the digest of payload, and its content is returned in the result.
+func (s *HookService) TestHook(ctx context.Context, id string, payload []byte, clientIP string) (*domain.TriggerResult, error) {
+	hook, err := s.repo.GetByID(id)
+	if err != nil {
+		s.log(ctx).Error("Failed to get hook for test", logger.Field{Key: "id", Value: id}, logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	content, err := s.createFlagFile(ctx, hook, clientIP, true, payload)
+	if err != nil {
+		s.log(ctx).Error("Failed to create flag file for test",
+			logger.Field{Key: "id", Value: id},
+			logger.Field{Key: "flag_file", Value: hook.FlagFile},
+			logger.Field{Key: "ip", Value: clientIP},
+			logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	s.log(ctx).Info("Hook test triggered",
+		logger.Field{Key: "id", Value: id},
+		logger.Field{Key: "name", Value: hook.Name},
+		logger.Field{Key: "flag_file", Value: hook.FlagFile},
+		logger.Field{Key: "labels", Value: hook.Labels},
+		logger.Field{Key: "ip", Value: clientIP},
+		logger.Field{Key: "payload_size", Value: len(payload)},
+		logger.Field{Key: "test", Value: true})
+
+	return &domain.TriggerResult{
+		HookID:      hook.ID,
+		FlagFile:    hook.FlagFile,
+		TriggeredAt: time.Now(),
+		Test:        true,
+		PayloadSize: len(payload),
+		Labels:      hook.Labels,
+		FlagContent: content,
+	}, nil
+}
+
 // GenerateToken generates a random token using current time and random bytes
 func (s *HookService) GenerateToken() string {
 	// Get current time as part of the token generation
@@ -171,43 +408,156 @@ func (s *HookService) GenerateToken() string {
 	return token
 }
 
+// validateHookID checks that a hook ID is a short slug safe for URLs and file names
+func validateHookID(id string) error {
+	v := validator.New()
+	v.NotEmpty(id, "id")
+	v.MaxLength(id, "id", maxHookIDLength)
+	if v.Valid() {
+		v.NoSpecialChars(id, "id")
+	}
+	v.Check(!reservedHookIDs[id], "id", "is reserved")
+
+	if !v.Valid() {
+		return domain.NewValidationError(v.GetErrors())
+	}
+	return nil
+}
+
+// generateHookID derives an ID from the hook name, falling back to a random
+// one. A random suffix is appended if the derived ID is already taken.
+func (s *HookService) generateHookID(name string) string {
+	id := slugify(name)
+	if id == "" {
+		return randomHookID()
+	}
+
+	if _, err := s.repo.GetByID(id); err == domain.ErrHookNotFound && !reservedHookIDs[id] {
+		return id
+	}
+
+	suffix := randomHookID()[:8]
+	if len(id)+1+len(suffix) > maxHookIDLength {
+		id = strings.TrimRight(id[:maxHookIDLength-1-len(suffix)], "-")
+	}
+	return id + "-" + suffix
+}
+
+// slugify converts a name to a lowercase ID made of letters, digits and dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimRight(b.String(), "-")
+	if len(slug) > maxHookIDLength {
+		slug = strings.TrimRight(slug[:maxHookIDLength], "-")
+	}
+	return slug
+}
+
+// randomHookID returns a random 16 character hex ID
+func randomHookID() string {
+	randomBytes := make([]byte, 8)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(randomBytes)
+}
+
+// ValidateHook validates a hook configuration loaded from outside the API,
+// such as a hooks file edited on disk
+func (s *HookService) ValidateHook(hook *domain.Hook) error {
+	return s.validateHook(hook)
+}
+
+// ValidateHookID checks the ID of a hook added outside the API, such as by
+// editing the hooks file on disk
+func (s *HookService) ValidateHookID(id string) error {
+	return validateHookID(id)
+}
+
 // validateHook validates a hook configuration
 func (s *HookService) validateHook(hook *domain.Hook) error {
+	v := validator.New()
+
 	// Check required fields
-	if hook.ID == "" {
-		return fmt.Errorf("hook ID is required")
-	}
-	if hook.Name == "" {
-		return fmt.Errorf("hook name is required")
-	}
+	v.NotEmpty(hook.ID, "id")
+	v.NotEmpty(hook.Name, "name")
+	v.MaxLength(hook.Name, "name", maxHookNameLength)
 	// Token validation is handled by the handler now
-	if hook.FlagFile == "" {
-		return fmt.Errorf("hook flag file is required")
-	}
+	v.NotEmpty(hook.FlagFile, "flag_file")
 
 	// Validate flag file path
-	if filepath.IsAbs(hook.FlagFile) {
-		return fmt.Errorf("flag file path must be relative: %s", hook.FlagFile)
-	}
+	v.Check(!filepath.IsAbs(hook.FlagFile), "flag_file", "must be a relative path")
 
 	// Check for path traversal
-	if strings.Contains(hook.FlagFile, "..") {
-		return fmt.Errorf("flag file path must not contain '..': %s", hook.FlagFile)
+	v.NoPathTraversal(hook.FlagFile, "flag_file")
+
+	// Tags are used in filters and must be simple words
+	v.Check(len(hook.Tags) <= maxHookTags, "tags", fmt.Sprintf("must not contain more than %d tags", maxHookTags))
+	for _, tag := range hook.Tags {
+		v.NotEmpty(tag, "tags")
+		v.MaxLength(tag, "tags", maxTagLength)
+		v.NoSpecialChars(tag, "tags")
+	}
+
+	// Labels are matched by selectors and written to flag files
+	v.Check(len(hook.Labels) <= maxHookLabels, "labels", fmt.Sprintf("must not contain more than %d labels", maxHookLabels))
+	for key, value := range hook.Labels {
+		v.Check(labels.ValidKey(key), "labels", fmt.Sprintf("key %q must be at most %d letters, digits, '.', '_', '/' or '-', starting and ending with a letter or digit", key, labels.MaxKeyLength))
+		v.Check(labels.ValidValue(value), "labels."+key, fmt.Sprintf("must be at most %d letters, digits, '.', '_' or '-'", labels.MaxValueLength))
 	}
 
+	if !v.Valid() {
+		return domain.NewValidationError(v.GetErrors())
+	}
 	return nil
 }
 
-// createFlagFile creates a flag file for a hook
-func (s *HookService) createFlagFile(hook *domain.Hook, clientIP string) error {
+// normalizeLabels returns nil for empty labels, so hooks without labels
+// compare equal however they were written
+func normalizeLabels(l map[string]string) map[string]string {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// normalizeTags sorts tags and removes duplicates, so equal sets of tags
+// compare equal
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return slices.Compact(sorted)
+}
+
+// createFlagFile creates a flag file for a hook and returns its content. Test
+// triggers record the size and SHA-256 digest of the sample payload, so a
+// consumer can check which payload a test file belongs to.
+func (s *HookService) createFlagFile(ctx context.Context, hook *domain.Hook, clientIP string, test bool, payload []byte) (string, error) {
+	start := time.Now()
+	defer func() { s.metrics.ObserveFlagWrite(time.Since(start)) }()
+
 	// Validate flag file path
 	if filepath.IsAbs(hook.FlagFile) {
-		return fmt.Errorf("flag file path must be relative: %s", hook.FlagFile)
+		return "", fmt.Errorf("flag file path must be relative: %s", hook.FlagFile)
 	}
 
 	// Check for path traversal
 	if strings.Contains(hook.FlagFile, "..") {
-		return fmt.Errorf("flag file path must not contain '..': %s", hook.FlagFile)
+		return "", fmt.Errorf("flag file path must not contain '..': %s", hook.FlagFile)
 	}
 
 	// Create absolute path
@@ -216,21 +566,43 @@ func (s *HookService) createFlagFile(hook *domain.Hook, clientIP string) error {
 	// Create directories
 	dir := filepath.Dir(flagFile)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Timestamp and client IP
+	var content strings.Builder
+	kind := "Hook triggered"
+	if test {
+		kind = "Hook test triggered"
+	}
+	fmt.Fprintf(&content, "%s at %s by client %s\n", kind, time.Now().Format(time.RFC3339), clientIP)
+
+	// Labels let downstream tools route on the flag file, in selector syntax
+	if len(hook.Labels) > 0 {
+		fmt.Fprintf(&content, "Labels: %s\n", labels.Format(hook.Labels))
+	}
+
+	// The request ID links the flag file to the log entries of the request
+	if id := domain.RequestIDFromContext(ctx); id != "" {
+		fmt.Fprintf(&content, "Request ID: %s\n", id)
+	}
+
+	if test {
+		sum := sha256.Sum256(payload)
+		fmt.Fprintf(&content, "Payload size: %d\n", len(payload))
+		fmt.Fprintf(&content, "Payload SHA-256: %s\n", hex.EncodeToString(sum[:]))
 	}
 
 	// Create file
 	file, err := os.Create(flagFile)
 	if err != nil {
-		return fmt.Errorf("failed to create flag file: %w", err)
+		return "", fmt.Errorf("failed to create flag file: %w", err)
 	}
 	defer file.Close()
 
-	// Write timestamp and client IP to file
-	_, err = fmt.Fprintf(file, "Hook triggered at %s by client %s\n", time.Now().Format(time.RFC3339), clientIP)
-	if err != nil {
-		return fmt.Errorf("failed to write to flag file: %w", err)
+	if _, err := file.WriteString(content.String()); err != nil {
+		return "", fmt.Errorf("failed to write to flag file: %w", err)
 	}
 
-	return nil
+	return content.String(), nil
 }
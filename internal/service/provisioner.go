@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// hookDefinition is the content of a single file in the provisioning directory
+type hookDefinition struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description" yaml:"description"`
+	Token       string            `json:"token" yaml:"token"`
+	FlagFile    string            `json:"flag_file" yaml:"flag_file"`
+	Enabled     *bool             `json:"enabled" yaml:"enabled"`
+	Tags        []string          `json:"tags" yaml:"tags"`
+	Labels      map[string]string `json:"labels" yaml:"labels"`
+}
+
+// Provisioner reconciles hooks with definitions in a directory, one YAML or
+// JSON file per hook. Hooks it creates are marked as managed: the API refuses
+// to change them, and they are deleted once their file is removed.
+type Provisioner struct {
+	dir     string
+	service *HookService
+	logger  logger.Logger
+
+	// mu serializes reconciliations, e.g. a SIGHUP during a dry run from the API
+	mu sync.Mutex
+}
+
+// NewProvisioner creates a provisioner for the definitions in dir
+func NewProvisioner(dir string, service *HookService, logger logger.Logger) *Provisioner {
+	return &Provisioner{
+		dir:     dir,
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Reconcile reads all definitions and brings the managed hooks in line with
+// them in a single batch. If any definition is invalid nothing is changed.
+// Declared IDs already used by hooks created through the API are reported as
+// conflicts and left alone. With dryRun set, only the drift is reported.
+func (p *Provisioner) Reconcile(ctx context.Context, dryRun bool) (*domain.ProvisionReport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	log := p.logger.WithContext(ctx)
+
+	declared, err := p.readDefinitions()
+	if err != nil {
+		log.Error("Failed to read hook definitions",
+			logger.Field{Key: "directory", Value: p.dir},
+			logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	current, err := p.service.repo.GetAll()
+	if err != nil {
+		log.Error("Failed to get hooks for provisioning", logger.Field{Key: "error", Value: err.Error()})
+		return nil, err
+	}
+
+	batch, report := p.plan(current, declared)
+	report.DryRun = dryRun
+
+	if len(report.Conflicts) > 0 {
+		log.Warn("Declared hooks conflict with hooks not managed by provisioning",
+			logger.Field{Key: "directory", Value: p.dir},
+			logger.Field{Key: "conflicts", Value: strings.Join(report.Conflicts, ",")})
+	}
+
+	if dryRun {
+		if !report.InSync() {
+			log.Info("Managed hooks drifted from their definitions",
+				logger.Field{Key: "directory", Value: p.dir},
+				logger.Field{Key: "missing", Value: strings.Join(report.Created, ",")},
+				logger.Field{Key: "changed", Value: strings.Join(report.Updated, ",")},
+				logger.Field{Key: "undeclared", Value: strings.Join(report.Deleted, ",")})
+		}
+		return report, nil
+	}
+
+	if len(batch.Create)+len(batch.Update)+len(batch.Delete) > 0 {
+		if err := p.service.repo.Apply(batch); err != nil {
+			log.Error("Failed to apply hook definitions",
+				logger.Field{Key: "directory", Value: p.dir},
+				logger.Field{Key: "error", Value: err.Error()})
+			return nil, err
+		}
+	}
+
+	log.Info("Hooks provisioned",
+		logger.Field{Key: "directory", Value: p.dir},
+		logger.Field{Key: "created", Value: strings.Join(report.Created, ",")},
+		logger.Field{Key: "updated", Value: strings.Join(report.Updated, ",")},
+		logger.Field{Key: "deleted", Value: strings.Join(report.Deleted, ",")},
+		logger.Field{Key: "unchanged", Value: len(report.Unchanged)})
+	return report, nil
+}
+
+// readDefinitions reads and validates all hook definitions in the directory.
+// Validation problems of all files are reported together.
+func (p *Provisioner) readDefinitions() ([]*domain.Hook, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provisioning directory: %w", err)
+	}
+
+	var hooks []*domain.Hook
+	fields := make(map[string]string)
+	files := make(map[string]string)
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		hook, err := readDefinition(filepath.Join(p.dir, entry.Name()))
+		if err != nil {
+			fields[entry.Name()] = err.Error()
+			continue
+		}
+
+		for _, err := range []error{validateHookID(hook.ID), p.service.validateHook(hook)} {
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) {
+				for field, msg := range validationErr.Fields {
+					fields[entry.Name()+"."+field] = msg
+				}
+			}
+		}
+
+		if other, ok := files[hook.ID]; ok {
+			fields[entry.Name()+".id"] = "is also declared in " + other
+			continue
+		}
+		files[hook.ID] = entry.Name()
+		hooks = append(hooks, hook)
+	}
+
+	if len(fields) > 0 {
+		return nil, domain.NewValidationError(fields)
+	}
+	return hooks, nil
+}
+
+// readDefinition decodes a single definition file. The ID defaults to the
+// file name without extension and hooks are enabled unless stated otherwise.
+func readDefinition(path string) (*domain.Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def hookDefinition
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&def)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	hook := &domain.Hook{
+		ID:          def.ID,
+		Name:        def.Name,
+		Description: def.Description,
+		Token:       def.Token,
+		FlagFile:    def.FlagFile,
+		Enabled:     def.Enabled == nil || *def.Enabled,
+		Tags:        normalizeTags(def.Tags),
+		Labels:      normalizeLabels(def.Labels),
+		Managed:     true,
+	}
+	if hook.ID == "" {
+		hook.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return hook, nil
+}
+
+// plan compares the declared hooks with the stored ones and returns the batch
+// that reconciles them together with a report of the drift. Written hooks are
+// pinned to the revisions read here.
+func (p *Provisioner) plan(current, declared []*domain.Hook) (*domain.HookBatch, *domain.ProvisionReport) {
+	batch := &domain.HookBatch{}
+	report := &domain.ProvisionReport{
+		Directory:    p.dir,
+		ReconciledAt: time.Now().UTC(),
+		Created:      []string{},
+		Updated:      []string{},
+		Deleted:      []string{},
+		Unchanged:    []string{},
+		Conflicts:    []string{},
+	}
+
+	existing := make(map[string]*domain.Hook, len(current))
+	for _, hook := range current {
+		existing[hook.ID] = hook
+	}
+
+	isDeclared := make(map[string]bool, len(declared))
+	for _, hook := range declared {
+		isDeclared[hook.ID] = true
+		old, ok := existing[hook.ID]
+
+		switch {
+		case !ok:
+			if hook.Token == "" {
+				hook.Token = p.service.GenerateToken()
+			}
+			batch.Create = append(batch.Create, hook)
+			report.Created = append(report.Created, hook.ID)
+
+		case !old.Managed:
+			report.Conflicts = append(report.Conflicts, hook.ID)
+
+		default:
+			// Definitions without a token keep the generated one
+			if hook.Token == "" {
+				hook.Token = old.Token
+			}
+			hook.Revision = old.Revision
+			hook.CreatedAt = old.CreatedAt
+			hook.UpdatedAt = old.UpdatedAt
+
+			if hook.Equal(old) {
+				report.Unchanged = append(report.Unchanged, hook.ID)
+				continue
+			}
+			batch.Update = append(batch.Update, hook)
+			report.Updated = append(report.Updated, hook.ID)
+		}
+	}
+
+	for _, hook := range current {
+		if hook.Managed && !isDeclared[hook.ID] {
+			batch.Delete = append(batch.Delete, &domain.Hook{ID: hook.ID, Revision: hook.Revision})
+			report.Deleted = append(report.Deleted, hook.ID)
+		}
+	}
+
+	sort.Strings(report.Created)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Deleted)
+	sort.Strings(report.Unchanged)
+	sort.Strings(report.Conflicts)
+	return batch, report
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces the file at path with data so that readers observe
+// either the old or the new content, never a partial write. The data is written
+// to a temporary file in the same directory, synced, and renamed over path.
+// If backupPath is not empty, the previous generation of the file is kept there.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, backupPath string) error {
+	dir := filepath.Dir(path)
+
+	// Write to a temp file in the same directory so rename stays on one filesystem
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temp file on any failure below
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	// Keep the previous generation before it is replaced
+	if backupPath != "" {
+		if err := backupFile(path, backupPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	committed = true
+
+	// Persist the rename itself
+	return syncDir(dir)
+}
+
+// backupFile makes backupPath refer to the current content of path.
+// A hard link is used when possible; otherwise the content is copied.
+func backupFile(path, backupPath string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old backup: %w", err)
+	}
+
+	if err := os.Link(path, backupPath); err == nil {
+		return nil
+	}
+
+	// Hard links are not supported everywhere (e.g. some mounted volumes)
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for backup: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("failed to read file for backup: %w", err)
+	}
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file for backup: %w", err)
+	}
+
+	return writeFileAtomic(backupPath, data, info.Mode().Perm(), "")
+}
+
+// syncDir fsyncs a directory so that renames inside it survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
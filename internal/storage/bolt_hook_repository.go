@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// Bucket and key names used in the bolt database
+var (
+	hooksBucket     = []byte("hooks")
+	metaBucket      = []byte("meta")
+	importedFromKey = []byte("imported_from")
+)
+
+// errBucketNotFound is returned if the database was not initialized
+var errBucketNotFound = errors.New("bucket not found")
+
+const (
+	// boltOpenTimeout limits how long to wait for another process holding the database
+	boltOpenTimeout = 5 * time.Second
+	// boltFileMode is the permission of a newly created database file
+	boltFileMode os.FileMode = 0600
+)
+
+// BoltHookRepository implements the HookRepository interface on an embedded
+// bbolt key-value database. Every operation runs in its own transaction and
+// only the affected hook is written.
+type BoltHookRepository struct {
+	db     *bolt.DB
+	logger logger.Logger
+}
+
+// NewBoltHookRepository opens (or creates) a bolt database at filePath
+func NewBoltHookRepository(filePath string, logger logger.Logger) (*BoltHookRepository, error) {
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	db, err := bolt.Open(filePath, boltFileMode, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hooks database: %w", err)
+	}
+
+	// Create buckets
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(hooksBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize hooks database: %w", err)
+	}
+
+	return &BoltHookRepository{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+// Close closes the database
+func (r *BoltHookRepository) Close() error {
+	return r.db.Close()
+}
+
+// GetByID returns a hook by ID
+func (r *BoltHookRepository) GetByID(id string) (*domain.Hook, error) {
+	var hook *domain.Hook
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		hook, err = getBoltHook(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// GetAll returns all hooks
+func (r *BoltHookRepository) GetAll() ([]*domain.Hook, error) {
+	var hooks []*domain.Hook
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(hooksBucket)
+		if b == nil {
+			return errBucketNotFound
+		}
+
+		hooks = make([]*domain.Hook, 0)
+		return b.ForEach(func(k, v []byte) error {
+			var hook domain.Hook
+			if err := json.Unmarshal(v, &hook); err != nil {
+				return fmt.Errorf("failed to decode hook %s: %w", k, err)
+			}
+			hooks = append(hooks, &hook)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// List returns the hooks matching a query
+func (r *BoltHookRepository) List(query domain.HookQuery) (*domain.HookPage, error) {
+	hooks, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return queryHooks(hooks, query)
+}
+
+// Create creates a new hook
+func (r *BoltHookRepository) Create(hook *domain.Hook) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		// Check if hook already exists
+		if _, err := getBoltHook(tx, hook.ID); err == nil {
+			return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
+		} else if err != domain.ErrHookNotFound {
+			return err
+		}
+
+		// Set creation time and initial revision
+		now := time.Now()
+		hook.CreatedAt = now
+		hook.UpdatedAt = now
+		hook.Revision = 1
+
+		return putBoltHook(tx, hook)
+	})
+}
+
+// Update updates an existing hook
+func (r *BoltHookRepository) Update(hook *domain.Hook) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		// Check if hook exists
+		existing, err := getBoltHook(tx, hook.ID)
+		if err != nil {
+			return err
+		}
+
+		// Reject stale writes
+		if hook.Revision != 0 && hook.Revision != existing.Revision {
+			return domain.ErrRevisionMismatch
+		}
+
+		// Creation time is owned by the repository
+		hook.CreatedAt = existing.CreatedAt
+
+		// Update time and revision
+		hook.UpdatedAt = time.Now()
+		hook.Revision = existing.Revision + 1
+
+		return putBoltHook(tx, hook)
+	})
+}
+
+// Delete deletes a hook
+func (r *BoltHookRepository) Delete(id string, revision int64) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		// Check if hook exists
+		existing, err := getBoltHook(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Reject stale deletes
+		if revision != 0 && revision != existing.Revision {
+			return domain.ErrRevisionMismatch
+		}
+
+		return tx.Bucket(hooksBucket).Delete([]byte(id))
+	})
+}
+
+// Apply applies a batch of changes in a single transaction
+func (r *BoltHookRepository) Apply(batch *domain.HookBatch) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+
+		for _, hook := range batch.Delete {
+			existing, err := getBoltHook(tx, hook.ID)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, hook.ID)
+			}
+			if hook.Revision != 0 && hook.Revision != existing.Revision {
+				return fmt.Errorf("%w: %s", domain.ErrRevisionMismatch, hook.ID)
+			}
+			if err := tx.Bucket(hooksBucket).Delete([]byte(hook.ID)); err != nil {
+				return err
+			}
+		}
+
+		for _, hook := range batch.Update {
+			existing, err := getBoltHook(tx, hook.ID)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, hook.ID)
+			}
+			if hook.Revision != 0 && hook.Revision != existing.Revision {
+				return fmt.Errorf("%w: %s", domain.ErrRevisionMismatch, hook.ID)
+			}
+			hook.CreatedAt = existing.CreatedAt
+			hook.UpdatedAt = now
+			hook.Revision = existing.Revision + 1
+			if err := putBoltHook(tx, hook); err != nil {
+				return err
+			}
+		}
+
+		for _, hook := range batch.Create {
+			if _, err := getBoltHook(tx, hook.ID); err == nil {
+				return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
+			} else if err != domain.ErrHookNotFound {
+				return err
+			}
+			hook.CreatedAt = now
+			hook.UpdatedAt = now
+			hook.Revision = 1
+			if err := putBoltHook(tx, hook); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ImportJSONFile performs a one-shot migration of hooks from a JSON hooks file.
+// It only runs if the database has never been imported into and holds no hooks,
+// so deleting hooks later does not bring them back on the next start.
+// It returns the number of imported hooks.
+func (r *BoltHookRepository) ImportJSONFile(path string) (int, error) {
+	if !fileExists(path) {
+		return 0, nil
+	}
+
+	imported := 0
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if meta.Get(importedFromKey) != nil {
+			return nil
+		}
+		if k, _ := tx.Bucket(hooksBucket).Cursor().First(); k != nil {
+			return nil
+		}
+
+		hooks, err := readHooksFile(path)
+		if err != nil && err != errEmptyHooksFile {
+			return err
+		}
+
+		for _, hook := range hooks {
+			if hook.ID == "" {
+				return fmt.Errorf("hook without ID in %s", path)
+			}
+			if hook.Revision == 0 {
+				hook.Revision = 1
+			}
+			if err := putBoltHook(tx, hook); err != nil {
+				return err
+			}
+		}
+		imported = len(hooks)
+
+		// Record the migration so it never runs twice
+		return meta.Put(importedFromKey, []byte(path))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import hooks from %s: %w", path, err)
+	}
+
+	if imported > 0 {
+		r.logger.Info("Imported hooks from JSON file",
+			logger.Field{Key: "file", Value: path},
+			logger.Field{Key: "count", Value: imported})
+	}
+
+	return imported, nil
+}
+
+// getBoltHook reads and decodes a hook inside a transaction
+func getBoltHook(tx *bolt.Tx, id string) (*domain.Hook, error) {
+	b := tx.Bucket(hooksBucket)
+	if b == nil {
+		return nil, errBucketNotFound
+	}
+
+	data := b.Get([]byte(id))
+	if data == nil {
+		return nil, domain.ErrHookNotFound
+	}
+
+	var hook domain.Hook
+	if err := json.Unmarshal(data, &hook); err != nil {
+		return nil, fmt.Errorf("failed to decode hook %s: %w", id, err)
+	}
+
+	return &hook, nil
+}
+
+// putBoltHook encodes and stores a hook inside a transaction
+func putBoltHook(tx *bolt.Tx, hook *domain.Hook) error {
+	data, err := json.Marshal(hook)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook %s: %w", hook.ID, err)
+	}
+
+	return tx.Bucket(hooksBucket).Put([]byte(hook.ID), data)
+}
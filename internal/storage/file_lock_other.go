@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+// lockFile is a no-op on platforms without flock; sharing a hooks file
+// between processes is only supported on Unix systems.
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"webhook-forge/internal/domain"
+)
+
+// hookCursor is the position after the last hook of a page. It records the
+// sort order it was issued for, so it cannot be used with a different one.
+type hookCursor struct {
+	SortBy     domain.HookSortField `json:"s"`
+	Descending bool                 `json:"d,omitempty"`
+	Value      string               `json:"v"`
+	ID         string               `json:"id"`
+}
+
+// encodeHookCursor returns an opaque cursor pointing after hook
+func encodeHookCursor(hook *domain.Hook, q domain.HookQuery) string {
+	c := hookCursor{SortBy: sortField(q), Descending: q.Descending, ID: hook.ID}
+	switch c.SortBy {
+	case domain.SortByName:
+		c.Value = hook.Name
+	case domain.SortByCreatedAt:
+		c.Value = hook.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case domain.SortByUpdatedAt:
+		c.Value = hook.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeHookCursor decodes the cursor of a query into a hook holding the sort
+// value and ID to continue after. It returns nil if the query has no cursor.
+func decodeHookCursor(q domain.HookQuery) (*domain.Hook, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var c hookCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+	if c.SortBy != sortField(q) || c.Descending != q.Descending {
+		return nil, fmt.Errorf("%w: issued for a different sort order", domain.ErrInvalidCursor)
+	}
+
+	hook := &domain.Hook{ID: c.ID}
+	switch c.SortBy {
+	case domain.SortByName:
+		hook.Name = c.Value
+	case domain.SortByCreatedAt, domain.SortByUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		hook.CreatedAt, hook.UpdatedAt = t, t
+	}
+	return hook, nil
+}
+
+// sortField returns the sort field of a query, defaulting to the ID
+func sortField(q domain.HookQuery) domain.HookSortField {
+	if q.SortBy == "" {
+		return domain.SortByID
+	}
+	return q.SortBy
+}
+
+// compareHooks orders two hooks by a sort field, then by ID
+func compareHooks(a, b *domain.Hook, field domain.HookSortField) int {
+	c := 0
+	switch field {
+	case domain.SortByName:
+		c = strings.Compare(a.Name, b.Name)
+	case domain.SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case domain.SortByUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// matchesHookQuery reports whether a hook passes the filters of a query
+func matchesHookQuery(hook *domain.Hook, q domain.HookQuery) bool {
+	if q.Enabled != nil && hook.Enabled != *q.Enabled {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(hook.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Tag != "" && !hook.HasTag(q.Tag) {
+		return false
+	}
+	if !q.Labels.Matches(hook.Labels) {
+		return false
+	}
+	return true
+}
+
+// queryHooks filters, sorts and pages hooks held in memory
+func queryHooks(hooks []*domain.Hook, q domain.HookQuery) (*domain.HookPage, error) {
+	after, err := decodeHookCursor(q)
+	if err != nil {
+		return nil, err
+	}
+
+	field := sortField(q)
+	order := func(a, b *domain.Hook) int {
+		if q.Descending {
+			return compareHooks(b, a, field)
+		}
+		return compareHooks(a, b, field)
+	}
+
+	selected := make([]*domain.Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		if !matchesHookQuery(hook, q) {
+			continue
+		}
+		if after != nil && order(hook, after) <= 0 {
+			continue
+		}
+		selected = append(selected, hook)
+	}
+	sort.Slice(selected, func(i, j int) bool { return order(selected[i], selected[j]) < 0 })
+
+	page := &domain.HookPage{Hooks: selected}
+	if q.Limit > 0 && len(selected) > q.Limit {
+		page.Hooks = selected[:q.Limit]
+		page.NextCursor = encodeHookCursor(page.Hooks[q.Limit-1], q)
+	}
+	return page, nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/labels"
+	"webhook-forge/pkg/logger"
+)
+
+// TestListMatchesAcrossDrivers runs the same queries through each backend,
+// so their filters cannot drift apart
+func TestListMatchesAcrossDrivers(t *testing.T) {
+	hooks := []*domain.Hook{
+		{ID: "e1", Name: "Émile deploy", Tags: []string{"deploy"}, Labels: map[string]string{"env": "prod"}},
+		{ID: "e2", Name: "ÉMILE backup", Tags: []string{"backup"}, Labels: map[string]string{"env": "staging"}},
+		{ID: "s1", Name: "Straße sync", Tags: []string{"sync", "deploy"}},
+		{ID: "p1", Name: "100% done", Labels: map[string]string{"team": "ops"}},
+		{ID: "u1", Name: "under_score", Labels: map[string]string{"env": "prod", "team": "web"}},
+	}
+
+	tests := []struct {
+		name     string
+		query    string // name filter
+		tag      string
+		selector string
+		want     []string
+	}{
+		{name: "all", want: []string{"e1", "e2", "p1", "s1", "u1"}},
+		{name: "non-ASCII lowercase", query: "émile", want: []string{"e1", "e2"}},
+		{name: "non-ASCII uppercase", query: "ÉMILE", want: []string{"e1", "e2"}},
+		{name: "non-ASCII in middle", query: "aße", want: []string{"s1"}},
+		{name: "ASCII case", query: "DEPLOY", want: []string{"e1"}},
+		{name: "percent is literal", query: "%", want: []string{"p1"}},
+		{name: "underscore is literal", query: "_", want: []string{"u1"}},
+		{name: "no match", query: "emile", want: []string{}},
+		{name: "tag", tag: "deploy", want: []string{"e1", "s1"}},
+		{name: "tag is exact", tag: "dep", want: []string{}},
+		{name: "label equals", selector: "env=prod", want: []string{"e1", "u1"}},
+		{name: "label not equals", selector: "env!=prod", want: []string{"e2", "p1", "s1"}},
+		{name: "label exists", selector: "team", want: []string{"p1", "u1"}},
+		{name: "label missing", selector: "!env", want: []string{"p1", "s1"}},
+		{name: "combined", query: "É", tag: "deploy", selector: "env=prod", want: []string{"e1"}},
+	}
+
+	dir := t.TempDir()
+	log := logger.New("error", "text", io.Discard)
+
+	jsonRepo, err := NewJSONHookRepository(filepath.Join(dir, "hooks.json"), log)
+	if err != nil {
+		t.Fatalf("NewJSONHookRepository() error = %v", err)
+	}
+	boltRepo, err := NewBoltHookRepository(filepath.Join(dir, "hooks.db"), log)
+	if err != nil {
+		t.Fatalf("NewBoltHookRepository() error = %v", err)
+	}
+	defer boltRepo.Close()
+	sqliteRepo, err := NewSQLHookRepository("sqlite", filepath.Join(dir, "hooks.sqlite"), log)
+	if err != nil {
+		t.Fatalf("NewSQLHookRepository() error = %v", err)
+	}
+	defer sqliteRepo.Close()
+
+	repos := []struct {
+		name string
+		repo domain.HookRepository
+	}{
+		{"json", jsonRepo},
+		{"bolt", boltRepo},
+		{"sqlite", sqliteRepo},
+	}
+	for _, r := range repos {
+		for _, hook := range hooks {
+			copied := *hook
+			if err := r.repo.Create(&copied); err != nil {
+				t.Fatalf("%s: Create(%s) error = %v", r.name, hook.ID, err)
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := labels.Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("labels.Parse(%q) error = %v", tt.selector, err)
+			}
+			query := domain.HookQuery{Name: tt.query, Tag: tt.tag, Labels: selector}
+
+			for _, r := range repos {
+				page, err := r.repo.List(query)
+				if err != nil {
+					t.Fatalf("%s: List() error = %v", r.name, err)
+				}
+				ids := make([]string, 0, len(page.Hooks))
+				for _, hook := range page.Hooks {
+					ids = append(ids, hook.ID)
+				}
+				if !slices.Equal(ids, tt.want) {
+					t.Errorf("%s: List() = %v, want %v", r.name, ids, tt.want)
+				}
+			}
+		})
+	}
+}
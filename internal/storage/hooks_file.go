@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"webhook-forge/internal/domain"
+)
+
+// currentHooksFileVersion is the schema version written by this build.
+// Version 0 is the legacy format: a bare JSON array of hooks.
+const currentHooksFileVersion = 1
+
+// errUnsupportedHooksFileVersion is returned for files written by a newer build.
+// Such files are never replaced by a backup or rewritten.
+var errUnsupportedHooksFileVersion = errors.New("unsupported hooks file version")
+
+// hooksFile is the on-disk envelope of the hooks file
+type hooksFile struct {
+	Version int               `json:"version"`
+	Hooks   []json.RawMessage `json:"hooks"`
+}
+
+// hooksFileMigration upgrades the hooks of a file from version-1 to version.
+// Hooks are passed as generic JSON objects so migrations do not depend on the
+// current shape of domain.Hook.
+type hooksFileMigration struct {
+	version     int
+	description string
+	migrate     func(hooks []map[string]interface{}) error
+}
+
+// hooksFileMigrations lists the upgrades applied to older hooks files, in order.
+// Never edit a released migration; append a new one and bump currentHooksFileVersion.
+var hooksFileMigrations = []hooksFileMigration{
+	{
+		version:     1,
+		description: "wrap hooks in a versioned envelope and initialize revisions",
+		migrate: func(hooks []map[string]interface{}) error {
+			for _, hook := range hooks {
+				if rev, ok := hook["revision"].(float64); !ok || rev == 0 {
+					hook["revision"] = 1
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// readHooksFile reads and decodes a hooks file of any supported version
+func readHooksFile(path string) ([]*domain.Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hooks file: %w", err)
+	}
+
+	hooks, _, err := decodeHooksFile(data)
+	return hooks, err
+}
+
+// decodeHooksFile decodes hooks file content, upgrading older versions in memory.
+// It returns the hooks and the version the content was stored in.
+func decodeHooksFile(data []byte) ([]*domain.Hook, int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, 0, errEmptyHooksFile
+	}
+
+	// Detect the legacy bare array form
+	var file hooksFile
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &file.Hooks); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode hooks file: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &file); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode hooks file: %w", err)
+	} else if file.Version < 1 {
+		return nil, 0, fmt.Errorf("hooks file has invalid version %d", file.Version)
+	}
+
+	if file.Version > currentHooksFileVersion {
+		return nil, file.Version, fmt.Errorf("%w: %d is newer than %d", errUnsupportedHooksFileVersion, file.Version, currentHooksFileVersion)
+	}
+
+	raw := file.Hooks
+	if file.Version < currentHooksFileVersion {
+		var err error
+		raw, err = migrateHooksFile(raw, file.Version)
+		if err != nil {
+			return nil, file.Version, err
+		}
+	}
+
+	hooks := make([]*domain.Hook, 0, len(raw))
+	for i, item := range raw {
+		var hook *domain.Hook
+		if err := json.Unmarshal(item, &hook); err != nil {
+			return nil, file.Version, fmt.Errorf("failed to decode hook at index %d: %w", i, err)
+		}
+		if hook == nil {
+			return nil, file.Version, fmt.Errorf("hook at index %d is null", i)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, file.Version, nil
+}
+
+// migrateHooksFile runs all migrations newer than version on raw hooks
+func migrateHooksFile(raw []json.RawMessage, version int) ([]json.RawMessage, error) {
+	hooks := make([]map[string]interface{}, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &hooks[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode hook at index %d: %w", i, err)
+		}
+	}
+
+	for _, m := range hooksFileMigrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.migrate(hooks); err != nil {
+			return nil, fmt.Errorf("hooks file migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+
+	migrated := make([]json.RawMessage, len(hooks))
+	for i, hook := range hooks {
+		data, err := json.Marshal(hook)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode migrated hook at index %d: %w", i, err)
+		}
+		migrated[i] = data
+	}
+
+	return migrated, nil
+}
+
+// encodeHooksFile encodes hooks in the current file format
+func encodeHooksFile(hooks []*domain.Hook) ([]byte, error) {
+	file := struct {
+		Version int            `json:"version"`
+		Hooks   []*domain.Hook `json:"hooks"`
+	}{
+		Version: currentHooksFileVersion,
+		Hooks:   hooks,
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -1,7 +1,7 @@
 package storage
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,20 +9,38 @@ import (
 	"time"
 
 	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
 )
 
+// errEmptyHooksFile is returned when a hooks file contains no data
+var errEmptyHooksFile = errors.New("hooks file is empty")
+
 // JSONHookRepository implements the HookRepository interface with JSON file storage
 type JSONHookRepository struct {
 	filePath string
 	hooks    map[string]*domain.Hook
+	logger   logger.Logger
 	mu       sync.RWMutex
+
+	// fingerprint identifies the file version last read or written by this process
+	fingerprint fileFingerprint
+
+	// rejected identifies the last file version that failed to parse or validate
+	rejected fileFingerprint
+
+	// validate checks hooks read from a file changed outside this process
+	validate HookValidator
+
+	// validateID checks the IDs of hooks added to the file outside this process
+	validateID HookIDValidator
 }
 
 // NewJSONHookRepository creates a new JSONHookRepository
-func NewJSONHookRepository(filePath string) (*JSONHookRepository, error) {
+func NewJSONHookRepository(filePath string, logger logger.Logger) (*JSONHookRepository, error) {
 	repo := &JSONHookRepository{
 		filePath: filePath,
 		hooks:    make(map[string]*domain.Hook),
+		logger:   logger,
 	}
 
 	// Create directory if it doesn't exist
@@ -31,8 +49,15 @@ func NewJSONHookRepository(filePath string) (*JSONHookRepository, error) {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	// Hold the file lock so another process does not initialize the store concurrently
+	unlock, err := lockFile(repo.lockPath(), true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Check if file or its backup exists
+	if !fileExists(filePath) && !fileExists(repo.backupPath()) {
 		// Create empty file
 		if err := repo.save(); err != nil {
 			return nil, fmt.Errorf("failed to create hooks file: %w", err)
@@ -47,8 +72,27 @@ func NewJSONHookRepository(filePath string) (*JSONHookRepository, error) {
 	return repo, nil
 }
 
+// SetHookValidator sets the function used to validate hooks written to the
+// file by other processes or edited by hand
+func (r *JSONHookRepository) SetHookValidator(validate HookValidator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validate = validate
+}
+
+// SetHookIDValidator sets the function used to check the IDs of hooks added
+// to the file by other processes or by hand. Hooks loaded at startup are not
+// checked, so existing IDs keep working.
+func (r *JSONHookRepository) SetHookIDValidator(validateID HookIDValidator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validateID = validateID
+}
+
 // GetByID returns a hook by ID
 func (r *JSONHookRepository) GetByID(id string) (*domain.Hook, error) {
+	r.refreshIfChanged()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -62,6 +106,8 @@ func (r *JSONHookRepository) GetByID(id string) (*domain.Hook, error) {
 
 // GetAll returns all hooks
 func (r *JSONHookRepository) GetAll() ([]*domain.Hook, error) {
+	r.refreshIfChanged()
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -73,20 +119,33 @@ func (r *JSONHookRepository) GetAll() ([]*domain.Hook, error) {
 	return hooks, nil
 }
 
+// List returns the hooks matching a query
+func (r *JSONHookRepository) List(query domain.HookQuery) (*domain.HookPage, error) {
+	hooks, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return queryHooks(hooks, query)
+}
+
 // Create creates a new hook
 func (r *JSONHookRepository) Create(hook *domain.Hook) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	unlock, err := r.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Check if hook already exists
 	if _, ok := r.hooks[hook.ID]; ok {
-		return fmt.Errorf("hook with ID %s already exists", hook.ID)
+		return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
 	}
 
-	// Set creation time
+	// Set creation time and initial revision
 	now := time.Now()
 	hook.CreatedAt = now
 	hook.UpdatedAt = now
+	hook.Revision = 1
 
 	// Add hook
 	r.hooks[hook.ID] = hook
@@ -97,16 +156,29 @@ func (r *JSONHookRepository) Create(hook *domain.Hook) error {
 
 // Update updates an existing hook
 func (r *JSONHookRepository) Update(hook *domain.Hook) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	unlock, err := r.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Check if hook exists
-	if _, ok := r.hooks[hook.ID]; !ok {
+	existing, ok := r.hooks[hook.ID]
+	if !ok {
 		return domain.ErrHookNotFound
 	}
 
-	// Update time
+	// Reject stale writes
+	if hook.Revision != 0 && hook.Revision != existing.Revision {
+		return domain.ErrRevisionMismatch
+	}
+
+	// Creation time is owned by the repository
+	hook.CreatedAt = existing.CreatedAt
+
+	// Update time and revision
 	hook.UpdatedAt = time.Now()
+	hook.Revision = existing.Revision + 1
 
 	// Update hook
 	r.hooks[hook.ID] = hook
@@ -116,15 +188,24 @@ func (r *JSONHookRepository) Update(hook *domain.Hook) error {
 }
 
 // Delete deletes a hook
-func (r *JSONHookRepository) Delete(id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *JSONHookRepository) Delete(id string, revision int64) error {
+	unlock, err := r.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Check if hook exists
-	if _, ok := r.hooks[id]; !ok {
+	existing, ok := r.hooks[id]
+	if !ok {
 		return domain.ErrHookNotFound
 	}
 
+	// Reject stale deletes
+	if revision != 0 && revision != existing.Revision {
+		return domain.ErrRevisionMismatch
+	}
+
 	// Delete hook
 	delete(r.hooks, id)
 
@@ -132,35 +213,218 @@ func (r *JSONHookRepository) Delete(id string) error {
 	return r.save()
 }
 
-// load loads hooks from file
-func (r *JSONHookRepository) load() error {
-	// Open file
-	file, err := os.Open(r.filePath)
+// Apply applies a batch of changes with a single save. The batch is checked
+// completely before anything is changed, and the hooks in memory are only
+// replaced once the file has been written.
+func (r *JSONHookRepository) Apply(batch *domain.HookBatch) error {
+	unlock, err := r.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	hooks := make(map[string]*domain.Hook, len(r.hooks))
+	for id, hook := range r.hooks {
+		hooks[id] = hook
+	}
+
+	now := time.Now()
+	for _, hook := range batch.Delete {
+		existing, ok := hooks[hook.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", domain.ErrHookNotFound, hook.ID)
+		}
+		if hook.Revision != 0 && hook.Revision != existing.Revision {
+			return fmt.Errorf("%w: %s", domain.ErrRevisionMismatch, hook.ID)
+		}
+		delete(hooks, hook.ID)
+	}
+	for _, hook := range batch.Update {
+		existing, ok := hooks[hook.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", domain.ErrHookNotFound, hook.ID)
+		}
+		if hook.Revision != 0 && hook.Revision != existing.Revision {
+			return fmt.Errorf("%w: %s", domain.ErrRevisionMismatch, hook.ID)
+		}
+		hook.CreatedAt = existing.CreatedAt
+		hook.UpdatedAt = now
+		hook.Revision = existing.Revision + 1
+		hooks[hook.ID] = hook
+	}
+	for _, hook := range batch.Create {
+		if _, ok := hooks[hook.ID]; ok {
+			return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
+		}
+		hook.CreatedAt = now
+		hook.UpdatedAt = now
+		hook.Revision = 1
+		hooks[hook.ID] = hook
+	}
+
+	previous := r.hooks
+	r.hooks = hooks
+	if err := r.save(); err != nil {
+		r.hooks = previous
+		return err
+	}
+
+	return nil
+}
+
+// lockForWrite locks the repository and the hooks file for a read-modify-write
+// cycle and picks up changes written by other processes since the last access.
+// The returned function releases both locks. If the file changed and cannot be
+// read, both locks are released and the error is returned, so the write does
+// not overwrite a version this process has not seen.
+func (r *JSONHookRepository) lockForWrite() (func(), error) {
+	r.mu.Lock()
+
+	unlockFile, err := lockFile(r.lockPath(), true)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	// A missing file is recreated from the hooks in memory by the write
+	if err := r.refreshLocked(true); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.logger.Error("Failed to refresh hooks before write, refusing to overwrite hooks file",
+			logger.Field{Key: "file", Value: r.filePath},
+			logger.Field{Key: "error", Value: err.Error()})
+		unlockFile()
+		r.mu.Unlock()
+		return nil, fmt.Errorf("hooks file changed on disk and cannot be loaded: %w", err)
+	}
+
+	return func() {
+		unlockFile()
+		r.mu.Unlock()
+	}, nil
+}
+
+// lockPath returns the path of the file used for inter-process locking.
+// The hooks file itself cannot be locked as it is replaced on every save.
+func (r *JSONHookRepository) lockPath() string {
+	return r.filePath + ".lock"
+}
+
+// CheckFile reports whether the hooks file can be read and parsed, as the
+// next reload would. A missing or empty file is a fresh store and passes.
+func (r *JSONHookRepository) CheckFile() error {
+	data, err := os.ReadFile(r.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open hooks file: %w", err)
 	}
-	defer file.Close()
+	if _, _, err := decodeHooksFile(data); err != nil && err != errEmptyHooksFile {
+		return err
+	}
+	return nil
+}
 
-	// Decode JSON
+// load loads hooks from file, falling back to the backup if the primary file is
+// corrupt. Files in an older format are upgraded and rewritten.
+func (r *JSONHookRepository) load() error {
 	var hooks []*domain.Hook
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&hooks); err != nil {
-		// If file is empty, return empty hooks
-		if err.Error() == "EOF" {
-			return nil
+	var version int
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		err = fmt.Errorf("failed to open hooks file: %w", err)
+	} else {
+		hooks, version, err = decodeHooksFile(data)
+	}
+	if errors.Is(err, errUnsupportedHooksFileVersion) {
+		return err
+	}
+	if err == nil && version < currentHooksFileVersion {
+		if err := r.upgrade(data, version); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to decode hooks file: %w", err)
+		defer func() {
+			if err := r.save(); err != nil {
+				r.logger.Error("Failed to write upgraded hooks file",
+					logger.Field{Key: "file", Value: r.filePath},
+					logger.Field{Key: "error", Value: err.Error()})
+			}
+		}()
+	}
+	if err != nil {
+		backupHooks, backupErr := readHooksFile(r.backupPath())
+		if backupErr != nil {
+			// An empty primary with no usable backup is a fresh store
+			if err == errEmptyHooksFile {
+				return nil
+			}
+			return err
+		}
+
+		// Keep the corrupt file for inspection and restore the primary from the backup
+		corruptPath := fmt.Sprintf("%s.corrupt-%s", r.filePath, time.Now().Format("20060102T150405"))
+		r.logger.Error("Hooks file is corrupt, falling back to backup",
+			logger.Field{Key: "file", Value: r.filePath},
+			logger.Field{Key: "backup", Value: r.backupPath()},
+			logger.Field{Key: "corrupt_copy", Value: corruptPath},
+			logger.Field{Key: "hooks", Value: len(backupHooks)},
+			logger.Field{Key: "error", Value: err.Error()})
+
+		if fileExists(r.filePath) {
+			if err := os.Rename(r.filePath, corruptPath); err != nil {
+				return fmt.Errorf("failed to move corrupt hooks file aside: %w", err)
+			}
+		}
+		hooks = backupHooks
+		defer func() {
+			if err := r.save(); err != nil {
+				r.logger.Error("Failed to restore hooks file from backup",
+					logger.Field{Key: "file", Value: r.filePath},
+					logger.Field{Key: "error", Value: err.Error()})
+			}
+		}()
 	}
 
 	// Add hooks to map
 	for _, hook := range hooks {
+		// Hooks written before revisions were introduced start at 1
+		if hook.Revision == 0 {
+			hook.Revision = 1
+		}
 		r.hooks[hook.ID] = hook
 	}
 
+	r.updateFingerprint()
 	return nil
 }
 
-// save saves hooks to file
+// upgrade keeps a permanent copy of a hooks file in an older format before it
+// is rewritten in the current format
+func (r *JSONHookRepository) upgrade(original []byte, version int) error {
+	copyPath := fmt.Sprintf("%s.v%d", r.filePath, version)
+	if err := writeFileAtomic(copyPath, original, 0644, ""); err != nil {
+		return fmt.Errorf("failed to back up hooks file before upgrade: %w", err)
+	}
+
+	r.logger.Warn("Upgrading hooks file format",
+		logger.Field{Key: "file", Value: r.filePath},
+		logger.Field{Key: "from_version", Value: version},
+		logger.Field{Key: "to_version", Value: currentHooksFileVersion},
+		logger.Field{Key: "original_copy", Value: copyPath})
+	return nil
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backupPath returns the path of the previous generation of the hooks file
+func (r *JSONHookRepository) backupPath() string {
+	return r.filePath + ".bak"
+}
+
+// save saves hooks to file atomically, keeping the previous version as a backup
 func (r *JSONHookRepository) save() error {
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(r.filePath)
@@ -168,13 +432,6 @@ func (r *JSONHookRepository) save() error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Open file
-	file, err := os.Create(r.filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create hooks file: %w", err)
-	}
-	defer file.Close()
-
 	// Convert map to slice
 	hooks := make([]*domain.Hook, 0, len(r.hooks))
 	for _, hook := range r.hooks {
@@ -182,11 +439,15 @@ func (r *JSONHookRepository) save() error {
 	}
 
 	// Encode JSON
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(hooks); err != nil {
+	data, err := encodeHooksFile(hooks)
+	if err != nil {
 		return fmt.Errorf("failed to encode hooks: %w", err)
 	}
 
+	if err := writeFileAtomic(r.filePath, data, 0644, r.backupPath()); err != nil {
+		return fmt.Errorf("failed to write hooks file: %w", err)
+	}
+
+	r.updateFingerprint()
 	return nil
 }
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/logger"
+)
+
+// fileFingerprint identifies a version of the hooks file on disk
+type fileFingerprint struct {
+	size    int64
+	modTime time.Time
+	hash    [sha256.Size]byte
+}
+
+// HookValidator validates a single hook loaded from disk
+type HookValidator func(hook *domain.Hook) error
+
+// HookIDValidator validates the ID of a hook added to the file outside the API
+type HookIDValidator func(id string) error
+
+// Watch polls the hooks file for external changes and reloads it until ctx is
+// cancelled. Changes are detected by size and modification time and confirmed
+// by content hash, so no platform-specific file notification API is needed.
+// Files that fail to parse or validate are rejected and the current hooks are kept.
+func (r *JSONHookRepository) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Watching hooks file for changes",
+		logger.Field{Key: "file", Value: r.filePath},
+		logger.Field{Key: "interval", Value: interval.String()})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reload()
+		}
+	}
+}
+
+// refreshIfChanged reloads the hooks file if it looks different from the
+// version this process last saw. It is cheap when nothing changed.
+func (r *JSONHookRepository) refreshIfChanged() {
+	r.mu.RLock()
+	changed := r.fileChangedLocked()
+	r.mu.RUnlock()
+
+	if changed {
+		r.reload()
+	}
+}
+
+// reload re-reads the hooks file under a shared file lock and logs rejected versions
+func (r *JSONHookRepository) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := lockFile(r.lockPath(), false)
+	if err != nil {
+		r.logger.Error("Failed to lock hooks file for reload",
+			logger.Field{Key: "file", Value: r.filePath},
+			logger.Field{Key: "error", Value: err.Error()})
+		return
+	}
+	defer unlock()
+
+	if err := r.refreshLocked(false); err != nil {
+		r.logger.Error("Rejected changed hooks file, keeping current hooks",
+			logger.Field{Key: "file", Value: r.filePath},
+			logger.Field{Key: "error", Value: err.Error()})
+	}
+}
+
+// fileChangedLocked reports whether the size or modification time of the hooks
+// file differs from the last version read or written, and from the last
+// version rejected. r.mu must be held.
+func (r *JSONHookRepository) fileChangedLocked() bool {
+	info, err := os.Stat(r.filePath)
+	if err != nil {
+		return false
+	}
+	return !r.fingerprint.matches(info) && !r.rejected.matches(info)
+}
+
+// matches reports whether info has the size and modification time of the fingerprint
+func (f fileFingerprint) matches(info os.FileInfo) bool {
+	return info.Size() == f.size && info.ModTime().Equal(f.modTime)
+}
+
+// refreshLocked re-reads the hooks file if it changed since it was last read
+// or written by this process. Without verify, a file whose size and
+// modification time are unchanged is assumed unchanged, and a version that was
+// already rejected is not parsed again. With verify, the content hash is always
+// compared and a rejected version is reported again, so that a write never
+// replaces a file it could not read. r.mu must be held for writing.
+func (r *JSONHookRepository) refreshLocked(verify bool) error {
+	info, err := os.Stat(r.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat hooks file: %w", err)
+	}
+	if !verify && (r.fingerprint.matches(info) || r.rejected.matches(info)) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read hooks file: %w", err)
+	}
+
+	current := fileFingerprint{size: info.Size(), modTime: info.ModTime(), hash: sha256.Sum256(data)}
+	if current.hash == r.fingerprint.hash {
+		r.fingerprint = current
+		return nil
+	}
+
+	hooks, err := parseHooks(data, r.hooks, r.validate, r.validateID)
+	if err != nil {
+		// Remember the rejected version, so the watcher reports it only once
+		r.rejected = current
+		return err
+	}
+	r.fingerprint = current
+
+	added, removed, changed := diffHooks(r.hooks, hooks)
+
+	// Bump revisions of externally edited hooks so stale ETags are rejected
+	for id, hook := range hooks {
+		if old, ok := r.hooks[id]; ok && hook.Revision <= old.Revision && contains(changed, id) {
+			hook.Revision = old.Revision + 1
+		}
+	}
+
+	r.hooks = hooks
+
+	r.logger.Info("Reloaded hooks file",
+		logger.Field{Key: "file", Value: r.filePath},
+		logger.Field{Key: "hooks", Value: len(hooks)},
+		logger.Field{Key: "added", Value: strings.Join(added, ",")},
+		logger.Field{Key: "removed", Value: strings.Join(removed, ",")},
+		logger.Field{Key: "changed", Value: strings.Join(changed, ",")})
+
+	return nil
+}
+
+// parseHooks decodes and validates the content of a hooks file. The IDs of
+// hooks not in existing are checked with validateID; hooks already loaded keep
+// IDs from before the ID rules were introduced.
+func parseHooks(data []byte, existing map[string]*domain.Hook, validate HookValidator, validateID HookIDValidator) (map[string]*domain.Hook, error) {
+	list, _, err := decodeHooksFile(data)
+	if err != nil && err != errEmptyHooksFile {
+		return nil, err
+	}
+
+	hooks := make(map[string]*domain.Hook, len(list))
+	for i, hook := range list {
+		if hook == nil || hook.ID == "" {
+			return nil, fmt.Errorf("hook at index %d has no ID", i)
+		}
+		if _, ok := hooks[hook.ID]; ok {
+			return nil, fmt.Errorf("duplicate hook ID %s", hook.ID)
+		}
+		if _, ok := existing[hook.ID]; !ok && validateID != nil {
+			if err := validateID(hook.ID); err != nil {
+				return nil, fmt.Errorf("invalid hook ID %q: %w", hook.ID, err)
+			}
+		}
+		if validate != nil {
+			if err := validate(hook); err != nil {
+				return nil, fmt.Errorf("invalid hook %s: %w", hook.ID, err)
+			}
+		}
+
+		// Hooks written before revisions were introduced start at 1
+		if hook.Revision == 0 {
+			hook.Revision = 1
+		}
+		hooks[hook.ID] = hook
+	}
+
+	return hooks, nil
+}
+
+// diffHooks returns the sorted IDs of hooks added, removed and changed between two sets
+func diffHooks(old, new map[string]*domain.Hook) (added, removed, changed []string) {
+	for id, hook := range new {
+		prev, ok := old[id]
+		if !ok {
+			added = append(added, id)
+			continue
+		}
+		if !sameHook(prev, hook) {
+			changed = append(changed, id)
+		}
+	}
+	for id := range old {
+		if _, ok := new[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// sameHook compares the user-editable content of two hooks
+func sameHook(a, b *domain.Hook) bool {
+	x, y := *a, *b
+	x.Revision, y.Revision = 0, 0
+	ja, errA := json.Marshal(x)
+	jb, errB := json.Marshal(y)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// contains reports whether ids contains id
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFingerprint records the current version of the hooks file so the
+// watcher does not reload changes made by this process
+func (r *JSONHookRepository) updateFingerprint() {
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(r.filePath)
+	if err != nil {
+		return
+	}
+	r.fingerprint = fileFingerprint{size: info.Size(), modTime: info.ModTime(), hash: sha256.Sum256(data)}
+}
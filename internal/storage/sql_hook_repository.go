@@ -0,0 +1,583 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Database drivers; both are pure Go so the binary stays statically linked
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"webhook-forge/internal/domain"
+	"webhook-forge/pkg/labels"
+	"webhook-forge/pkg/logger"
+)
+
+// sqlDialect holds the differences between supported SQL databases
+type sqlDialect struct {
+	name       string
+	driverName string
+	// numbered reports whether placeholders are $1, $2, ... instead of ?
+	numbered bool
+	// binaryCollation makes text compare byte by byte, as Go strings do
+	binaryCollation string
+}
+
+// Supported SQL dialects, keyed by storage driver name
+var sqlDialects = map[string]sqlDialect{
+	"sqlite":   {name: "sqlite", driverName: "sqlite"},
+	"postgres": {name: "postgres", driverName: "pgx", numbered: true, binaryCollation: ` COLLATE "C"`},
+}
+
+// rebind converts ? placeholders to the dialect's placeholder syntax
+func (d sqlDialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hookColumns is the column list used by all hook queries, in scan order
+const hookColumns = "id, name, description, token, flag_file, enabled, tags, labels, managed, revision, created_at, updated_at"
+
+// SQLHookRepository implements the HookRepository interface on a SQL database
+// through database/sql. SQLite and PostgreSQL are supported.
+type SQLHookRepository struct {
+	db      *sql.DB
+	dialect sqlDialect
+	logger  logger.Logger
+}
+
+// NewSQLHookRepository connects to the database and applies pending schema migrations
+func NewSQLHookRepository(driver string, dsn string, logger logger.Logger) (*SQLHookRepository, error) {
+	dialect, ok := sqlDialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SQL driver: %s", driver)
+	}
+	if dsn == "" {
+		return nil, fmt.Errorf("storage_dsn is required for the %s driver", driver)
+	}
+
+	db, err := sql.Open(dialect.driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer; serializing connections avoids SQLITE_BUSY errors
+	if dialect.name == "sqlite" {
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := migrateSQL(db, dialect, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLHookRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}, nil
+}
+
+// Close closes the database connection pool
+func (r *SQLHookRepository) Close() error {
+	return r.db.Close()
+}
+
+// GetByID returns a hook by ID
+func (r *SQLHookRepository) GetByID(id string) (*domain.Hook, error) {
+	row := r.db.QueryRow(r.dialect.rebind(`SELECT `+hookColumns+` FROM hooks WHERE id = ?`), id)
+
+	hook, err := scanHook(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrHookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hook %s: %w", id, err)
+	}
+
+	return hook, nil
+}
+
+// GetAll returns all hooks
+func (r *SQLHookRepository) GetAll() ([]*domain.Hook, error) {
+	rows, err := r.db.Query(`SELECT ` + hookColumns + ` FROM hooks`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hooks: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := make([]*domain.Hook, 0)
+	for rows.Next() {
+		hook, err := scanHook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// List returns the hooks matching a query. Filtering, ordering and paging
+// happen in the database, using keyset pagination on (sort field, id).
+func (r *SQLHookRepository) List(query domain.HookQuery) (*domain.HookPage, error) {
+	after, err := decodeHookCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	field := sortField(query)
+	sortExpr := string(field)
+	if field == domain.SortByID || field == domain.SortByName {
+		sortExpr += r.dialect.binaryCollation
+	}
+	idExpr := "id" + r.dialect.binaryCollation
+
+	var where []string
+	var args []interface{}
+	if query.Enabled != nil {
+		where = append(where, "enabled = ?")
+		args = append(args, *query.Enabled)
+	}
+	if query.Name != "" {
+		// name_lower is set with strings.ToLower, so non-ASCII names
+		// match as they do in the other backends
+		where = append(where, `name_lower LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.ToLower(query.Name))+"%")
+	}
+	if query.Tag != "" {
+		where = append(where, `tags LIKE ? ESCAPE '\'`)
+		args = append(args, `%"`+escapeLike(query.Tag)+`"%`)
+	}
+	// Labels are stored as JSON with restricted characters, so each
+	// requirement can be matched as a substring
+	for _, req := range query.Labels {
+		pattern := `%"` + escapeLike(req.Key) + `":`
+		if req.Operator == labels.Equals || req.Operator == labels.NotEquals {
+			pattern += `"` + escapeLike(req.Value) + `"`
+		}
+		pattern += "%"
+
+		if req.Operator == labels.NotEquals || req.Operator == labels.DoesNotExist {
+			where = append(where, `labels NOT LIKE ? ESCAPE '\'`)
+		} else {
+			where = append(where, `labels LIKE ? ESCAPE '\'`)
+		}
+		args = append(args, pattern)
+	}
+
+	op, dir := ">", "ASC"
+	if query.Descending {
+		op, dir = "<", "DESC"
+	}
+	if after != nil {
+		if field == domain.SortByID {
+			where = append(where, idExpr+" "+op+" ?")
+			args = append(args, after.ID)
+		} else {
+			value := sortValue(after, field)
+			where = append(where, "("+sortExpr+" "+op+" ? OR ("+sortExpr+" = ? AND "+idExpr+" "+op+" ?))")
+			args = append(args, value, value, after.ID)
+		}
+	}
+
+	sqlQuery := `SELECT ` + hookColumns + ` FROM hooks`
+	if len(where) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	sqlQuery += ` ORDER BY ` + sortExpr + ` ` + dir
+	if field != domain.SortByID {
+		sqlQuery += `, ` + idExpr + ` ` + dir
+	}
+	if query.Limit > 0 {
+		// Fetch one more row to know whether there is a next page
+		sqlQuery += ` LIMIT ?`
+		args = append(args, query.Limit+1)
+	}
+
+	rows, err := r.db.Query(r.dialect.rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hooks: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := make([]*domain.Hook, 0)
+	for rows.Next() {
+		hook, err := scanHook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hooks: %w", err)
+	}
+
+	page := &domain.HookPage{Hooks: hooks}
+	if query.Limit > 0 && len(hooks) > query.Limit {
+		page.Hooks = hooks[:query.Limit]
+		page.NextCursor = encodeHookCursor(page.Hooks[query.Limit-1], query)
+	}
+	return page, nil
+}
+
+// Create creates a new hook
+func (r *SQLHookRepository) Create(hook *domain.Hook) error {
+	// Set creation time and initial revision
+	now := time.Now().UTC()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	hook.Revision = 1
+
+	inserted, err := insertHook(r.db, r.dialect, hook)
+	if err != nil {
+		return fmt.Errorf("failed to create hook %s: %w", hook.ID, err)
+	}
+	if !inserted {
+		return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
+	}
+
+	return nil
+}
+
+// Update updates an existing hook. The revision check and increment happen
+// in the same statement, so concurrent writers cannot overwrite each other.
+func (r *SQLHookRepository) Update(hook *domain.Hook) error {
+	if err := updateHook(r.db, r.dialect, hook, time.Now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrHookNotFound) || errors.Is(err, domain.ErrRevisionMismatch) {
+			return err
+		}
+		return fmt.Errorf("failed to update hook %s: %w", hook.ID, err)
+	}
+	return nil
+}
+
+// Delete deletes a hook
+func (r *SQLHookRepository) Delete(id string, revision int64) error {
+	if err := deleteHook(r.db, r.dialect, id, revision); err != nil {
+		if errors.Is(err, domain.ErrHookNotFound) || errors.Is(err, domain.ErrRevisionMismatch) {
+			return err
+		}
+		return fmt.Errorf("failed to delete hook %s: %w", id, err)
+	}
+	return nil
+}
+
+// Apply applies a batch of changes in a single transaction
+func (r *SQLHookRepository) Apply(batch *domain.HookBatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	for _, hook := range batch.Delete {
+		if err := deleteHook(tx, r.dialect, hook.ID, hook.Revision); err != nil {
+			return fmt.Errorf("failed to delete hook %s: %w", hook.ID, err)
+		}
+	}
+
+	for _, hook := range batch.Update {
+		if err := updateHook(tx, r.dialect, hook, now); err != nil {
+			return fmt.Errorf("failed to update hook %s: %w", hook.ID, err)
+		}
+	}
+
+	for _, hook := range batch.Create {
+		hook.CreatedAt = now
+		hook.UpdatedAt = now
+		hook.Revision = 1
+
+		inserted, err := insertHook(tx, r.dialect, hook)
+		if err != nil {
+			return fmt.Errorf("failed to create hook %s: %w", hook.ID, err)
+		}
+		if !inserted {
+			return fmt.Errorf("%w: %s", domain.ErrHookExists, hook.ID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	return nil
+}
+
+// ImportJSONFile performs a one-shot migration of hooks from a JSON hooks file
+// in a single transaction. It only runs if no import was recorded before and
+// the hooks table is empty. It returns the number of imported hooks.
+func (r *SQLHookRepository) ImportJSONFile(path string) (int, error) {
+	if !fileExists(path) {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	var marker string
+	err = tx.QueryRow(r.dialect.rebind(`SELECT value FROM storage_meta WHERE key = ?`), "imported_from").Scan(&marker)
+	if err == nil {
+		return 0, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to check import marker: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM hooks`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count hooks: %w", err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	hooks, err := readHooksFile(path)
+	if err != nil && err != errEmptyHooksFile {
+		return 0, fmt.Errorf("failed to import hooks from %s: %w", path, err)
+	}
+
+	for _, hook := range hooks {
+		if hook.ID == "" {
+			return 0, fmt.Errorf("failed to import hooks from %s: hook without ID", path)
+		}
+		if hook.Revision == 0 {
+			hook.Revision = 1
+		}
+		if _, err := insertHook(tx, r.dialect, hook); err != nil {
+			return 0, fmt.Errorf("failed to import hook %s: %w", hook.ID, err)
+		}
+	}
+
+	// Record the migration so it never runs twice
+	if _, err := tx.Exec(r.dialect.rebind(`INSERT INTO storage_meta (key, value) VALUES (?, ?)`), "imported_from", path); err != nil {
+		return 0, fmt.Errorf("failed to record import: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	if len(hooks) > 0 {
+		r.logger.Info("Imported hooks from JSON file",
+			logger.Field{Key: "file", Value: path},
+			logger.Field{Key: "count", Value: len(hooks)})
+	}
+
+	return len(hooks), nil
+}
+
+// updateHook updates a hook, checking and incrementing its revision in the same statement
+func updateHook(db sqlQueryer, d sqlDialect, hook *domain.Hook, now time.Time) error {
+	tags, labels, err := encodeHookMetadata(hook)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE hooks SET name = ?, name_lower = ?, description = ?, token = ?, flag_file = ?, enabled = ?, tags = ?, labels = ?,
+		managed = ?, revision = revision + 1, updated_at = ?
+		WHERE id = ?`
+	args := []interface{}{hook.Name, strings.ToLower(hook.Name), hook.Description, hook.Token, hook.FlagFile, hook.Enabled, tags, labels, hook.Managed, now, hook.ID}
+
+	// Reject stale writes
+	if hook.Revision != 0 {
+		query += ` AND revision = ?`
+		args = append(args, hook.Revision)
+	}
+	query += ` RETURNING revision, created_at`
+
+	var revision int64
+	var createdAt time.Time
+	err = db.QueryRow(d.rebind(query), args...).Scan(&revision, &createdAt)
+	if err == sql.ErrNoRows {
+		return missOrMismatch(db, d, hook.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Reflect the stored state in the caller's hook
+	hook.Revision = revision
+	hook.CreatedAt = createdAt
+	hook.UpdatedAt = now
+
+	return nil
+}
+
+// deleteHook deletes a hook, checking its revision if non-zero
+func deleteHook(db sqlQueryer, d sqlDialect, id string, revision int64) error {
+	query := `DELETE FROM hooks WHERE id = ?`
+	args := []interface{}{id}
+
+	// Reject stale deletes
+	if revision != 0 {
+		query += ` AND revision = ?`
+		args = append(args, revision)
+	}
+
+	result, err := db.Exec(d.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return missOrMismatch(db, d, id)
+	}
+
+	return nil
+}
+
+// missOrMismatch tells apart a missing hook from a stale revision after a
+// conditional statement matched no rows
+func missOrMismatch(db sqlQueryer, d sqlDialect, id string) error {
+	var exists int
+	err := db.QueryRow(d.rebind(`SELECT 1 FROM hooks WHERE id = ?`), id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return domain.ErrHookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check hook %s: %w", id, err)
+	}
+	return domain.ErrRevisionMismatch
+}
+
+// sqlExecer is implemented by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertHook inserts a hook unless one with the same ID exists and reports whether it was inserted
+func insertHook(db sqlExecer, d sqlDialect, hook *domain.Hook) (bool, error) {
+	tags, labels, err := encodeHookMetadata(hook)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := db.Exec(d.rebind(`INSERT INTO hooks (`+hookColumns+`, name_lower)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`),
+		hook.ID, hook.Name, hook.Description, hook.Token, hook.FlagFile, hook.Enabled, tags, labels, hook.Managed,
+		hook.Revision, hook.CreatedAt.UTC(), hook.UpdatedAt.UTC(), strings.ToLower(hook.Name))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// sqlQueryer is implemented by both *sql.DB and *sql.Tx
+type sqlQueryer interface {
+	sqlExecer
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// sqlScanner is implemented by both *sql.Row and *sql.Rows
+type sqlScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanHook reads a hook from a row selected with hookColumns
+func scanHook(row sqlScanner) (*domain.Hook, error) {
+	var hook domain.Hook
+	var tags, labels string
+	err := row.Scan(&hook.ID, &hook.Name, &hook.Description, &hook.Token, &hook.FlagFile,
+		&hook.Enabled, &tags, &labels, &hook.Managed, &hook.Revision, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &hook.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags of hook %s: %w", hook.ID, err)
+	}
+	if err := json.Unmarshal([]byte(labels), &hook.Labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels of hook %s: %w", hook.ID, err)
+	}
+	if len(hook.Labels) == 0 {
+		hook.Labels = nil
+	}
+	return &hook, nil
+}
+
+// encodeHookMetadata encodes the tags and labels of a hook as JSON for the
+// tags and labels columns
+func encodeHookMetadata(hook *domain.Hook) (string, string, error) {
+	tags := hook.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagData, err := json.Marshal(tags)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	labels := hook.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labelData, err := json.Marshal(labels)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode labels: %w", err)
+	}
+
+	return string(tagData), string(labelData), nil
+}
+
+// sortValue returns the value of the sort field of a hook as a query argument
+func sortValue(hook *domain.Hook, field domain.HookSortField) interface{} {
+	switch field {
+	case domain.SortByName:
+		return hook.Name
+	case domain.SortByCreatedAt:
+		return hook.CreatedAt.UTC()
+	case domain.SortByUpdatedAt:
+		return hook.UpdatedAt.UTC()
+	}
+	return hook.ID
+}
+
+// escapeLike escapes the LIKE wildcards in s, using backslash as escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
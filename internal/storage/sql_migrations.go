@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"webhook-forge/pkg/logger"
+)
+
+// sqlMigration is a versioned schema change. Migrations are applied in order
+// of version and each one runs in its own transaction.
+type sqlMigration struct {
+	version     int
+	description string
+	statements  []string
+	// backfill fills new columns with values computed in Go, after statements
+	backfill func(tx *sql.Tx, d sqlDialect) error
+}
+
+// sqlMigrations lists all schema migrations. Never edit or reorder a migration
+// that has been released; add a new one with the next version instead.
+var sqlMigrations = []sqlMigration{
+	{
+		version:     1,
+		description: "create hooks table",
+		statements: []string{
+			`CREATE TABLE hooks (
+				id          VARCHAR(64)  PRIMARY KEY,
+				name        VARCHAR(128) NOT NULL,
+				description TEXT         NOT NULL,
+				token       TEXT         NOT NULL,
+				flag_file   TEXT         NOT NULL,
+				enabled     BOOLEAN      NOT NULL,
+				revision    BIGINT       NOT NULL,
+				created_at  TIMESTAMP    NOT NULL,
+				updated_at  TIMESTAMP    NOT NULL
+			)`,
+			`CREATE TABLE storage_meta (
+				key   VARCHAR(64) PRIMARY KEY,
+				value TEXT        NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "add managed flag to hooks",
+		statements: []string{
+			`ALTER TABLE hooks ADD COLUMN managed BOOLEAN NOT NULL DEFAULT FALSE`,
+		},
+	},
+	{
+		version:     3,
+		description: "add tags to hooks",
+		statements: []string{
+			// Tags are stored as a JSON array of strings
+			`ALTER TABLE hooks ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		version:     4,
+		description: "add labels to hooks",
+		statements: []string{
+			// Labels are stored as a JSON object with keys in sorted order
+			`ALTER TABLE hooks ADD COLUMN labels TEXT NOT NULL DEFAULT '{}'`,
+		},
+	},
+	{
+		version:     5,
+		description: "add lowercased name to hooks",
+		statements: []string{
+			// The name is lowercased in Go, as SQLite's LOWER only folds ASCII letters
+			`ALTER TABLE hooks ADD COLUMN name_lower TEXT NOT NULL DEFAULT ''`,
+		},
+		backfill: backfillNameLower,
+	},
+}
+
+// backfillNameLower sets name_lower for hooks stored before it existed
+func backfillNameLower(tx *sql.Tx, d sqlDialect) error {
+	rows, err := tx.Query(`SELECT id, name FROM hooks`)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		names[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, name := range names {
+		if _, err := tx.Exec(d.rebind(`UPDATE hooks SET name_lower = ? WHERE id = ?`), strings.ToLower(name), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSQL brings the database schema up to date
+func migrateSQL(db *sql.DB, d sqlDialect, log logger.Logger) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER   PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, m := range sqlMigrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := applySQLMigration(db, d, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+
+		log.Info("Applied database migration",
+			logger.Field{Key: "version", Value: m.version},
+			logger.Field{Key: "description", Value: m.description})
+	}
+
+	return nil
+}
+
+// applySQLMigration runs a single migration and records it in schema_migrations
+func applySQLMigration(db *sql.DB, d sqlDialect, m sqlMigration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if m.backfill != nil {
+		if err := m.backfill(tx, d); err != nil {
+			return err
+		}
+	}
+
+	// A concurrent process applying the same migration fails here on the primary key
+	if _, err := tx.Exec(d.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), m.version, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,81 @@
+// Package version reports the version the binaries were built from. The
+// values are set at build time with -ldflags, for example:
+//
+//	go build -ldflags "-X webhook-forge/internal/version.Version=1.2.0 \
+//	  -X webhook-forge/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X webhook-forge/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values not set this way are taken from the build information Go embeds in
+// binaries built inside the git repository.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X webhook-forge/internal/version.<name>=<value>"
+var (
+	Version = "dev"     // Release version, e.g. 1.2.0
+	Commit  = "unknown" // Git commit the binary was built from
+	Date    = "unknown" // Build time in RFC 3339 format
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		Version = info.Main.Version
+	}
+
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			if Date == "unknown" {
+				Date = setting.Value
+			}
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if Commit == "unknown" && revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		Commit = revision
+		if modified == "true" {
+			Commit += "-dirty"
+		}
+	}
+}
+
+// Info describes the build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String returns the build information as printed by --version, prefixed
+// with the program name
+func String(program string) string {
+	return fmt.Sprintf("%s %s (commit %s, built %s, %s %s/%s)",
+		program, Version, Commit, Date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
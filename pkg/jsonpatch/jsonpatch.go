@@ -0,0 +1,366 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types for the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Common errors
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrTestFailed   = errors.New("patch test operation failed")
+)
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to a JSON document
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+// mergeValue implements the MergePatch algorithm from RFC 7396 section 2
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// Operation represents a single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// hasValue records whether the decoded operation had a value member,
+	// which may be null
+	hasValue bool
+}
+
+// UnmarshalJSON decodes an operation, noting whether it has a value member
+// so that "value": null can be told apart from a missing value
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	type operation Operation
+	if err := json.Unmarshal(data, (*operation)(o)); err != nil {
+		return err
+	}
+	_, o.hasValue = members["value"]
+	return nil
+}
+
+// Apply applies an RFC 6902 JSON Patch to a JSON document.
+// Operations are applied in order; if any fails, the document is left untouched.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// applyOperation applies a single operation and returns the new document root
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if !op.hasValue && op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			return replace(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+			}
+			var value interface{}
+			doc, value, err = remove(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with '/'", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// isPrefix reports whether prefix is a leading subsequence of path
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the value referenced by path
+func get(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path member %q not found", ErrInvalidPatch, token)
+			}
+			current = value
+		case []interface{}:
+			idx, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = container[idx]
+		default:
+			return nil, fmt.Errorf("%w: cannot traverse into scalar at %q", ErrInvalidPatch, token)
+		}
+	}
+	return current, nil
+}
+
+// add inserts value at path, following the "add" rules of RFC 6902 section 4.1
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+		return doc, nil
+	case []interface{}:
+		idx := len(container)
+		if last != "-" {
+			idx, err = arrayIndex(last, len(container))
+			if err != nil {
+				return nil, err
+			}
+		}
+		updated := make([]interface{}, 0, len(container)+1)
+		updated = append(updated, container[:idx]...)
+		updated = append(updated, value)
+		updated = append(updated, container[idx:]...)
+		return replace(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: cannot add to scalar at %q", ErrInvalidPatch, last)
+	}
+}
+
+// replace sets the value at an existing path
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[last] = value
+	case []interface{}:
+		idx, err := arrayIndex(last, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		container[idx] = value
+	default:
+		return nil, fmt.Errorf("%w: cannot replace in scalar at %q", ErrInvalidPatch, last)
+	}
+
+	return doc, nil
+}
+
+// remove deletes the value at path and returns the new document and the removed value
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, ok := container[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: path member %q not found", ErrInvalidPatch, last)
+		}
+		delete(container, last)
+		return doc, value, nil
+	case []interface{}:
+		idx, err := arrayIndex(last, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := container[idx]
+		updated := make([]interface{}, 0, len(container)-1)
+		updated = append(updated, container[:idx]...)
+		updated = append(updated, container[idx+1:]...)
+		doc, err = replace(doc, path[:len(path)-1], updated)
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("%w: cannot remove from scalar at %q", ErrInvalidPatch, last)
+	}
+}
+
+// arrayIndex parses an array index token and checks it is within [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > max {
+		return 0, fmt.Errorf("%w: array index %q out of range", ErrInvalidPatch, token)
+	}
+	return idx, nil
+}
+
+// deepCopy returns a copy of a decoded JSON value that shares no containers with the original
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			copied[k] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
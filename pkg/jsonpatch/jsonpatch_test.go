@@ -0,0 +1,150 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSON fails the test if got and want are not the same JSON value
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v: %s", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("expected value is not JSON: %v", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null removes member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"null for missing member", `{"a":"b"}`, `{"x":null}`, `{"a":"b"}`},
+		{"arrays are replaced", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"nested merge", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":null,"f":"g"}}`, `{"a":{"d":"e","f":"g"}}`},
+		{"object replaces scalar", `{"a":"b"}`, `{"a":{"c":null,"d":"e"}}`, `{"a":{"d":"e"}}`},
+		{"non-object patch replaces document", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"b"}`, `null`, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("MergePatch() error = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add null member", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+		{"add to array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"append to array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"replace member", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"replace with null", `{"a":1}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`},
+		{"replace document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array element", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`},
+		{"move member", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"copy member", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test passes", `{"a":[1,{"b":"c"}]}`, `[{"op":"test","path":"/a","value":[1,{"b":"c"}]}]`, `{"a":[1,{"b":"c"}]}`},
+		{"test null", `{"a":null}`, `[{"op":"test","path":"/a","value":null}]`, `{"a":null}`},
+		{"escaped pointer", `{"a/b":1,"c~d":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`, `{"a/b":3}`},
+		{"operations in order", `{}`, `[{"op":"add","path":"/a","value":[]},{"op":"add","path":"/a/-","value":1}]`, `{"a":[1]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  error
+	}{
+		{"malformed patch", `{}`, `{"op":"add"}`, ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, ErrInvalidPatch},
+		{"unsupported operation", `{}`, `[{"op":"merge","path":"/a","value":1}]`, ErrInvalidPatch},
+		{"pointer without slash", `{}`, `[{"op":"add","path":"a","value":1}]`, ErrInvalidPatch},
+		{"replace missing member", `{}`, `[{"op":"replace","path":"/a","value":1}]`, ErrInvalidPatch},
+		{"remove missing member", `{}`, `[{"op":"remove","path":"/a"}]`, ErrInvalidPatch},
+		{"array index out of range", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, ErrInvalidPatch},
+		{"array index with leading zero", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, ErrInvalidPatch},
+		{"move into child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, ErrInvalidPatch},
+		{"test fails", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, ErrTestFailed},
+		{"test null fails", `{"a":1}`, `[{"op":"test","path":"/a","value":null}]`, ErrTestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Apply([]byte(tt.doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+				t.Errorf("Apply() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLeavesDocumentOnFailure(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	patch := []byte(`[{"op":"add","path":"/b","value":2},{"op":"test","path":"/a","value":3}]`)
+	if _, err := Apply(doc, patch); !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("Apply() error = %v, want ErrTestFailed", err)
+	}
+	assertJSON(t, doc, `{"a":1}`)
+}
+
+func TestOperationValue(t *testing.T) {
+	var ops []Operation
+	if err := json.Unmarshal([]byte(`[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/a"}]`), &ops); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !ops[0].hasValue {
+		t.Error("null value not recorded as present")
+	}
+	if ops[1].hasValue {
+		t.Error("missing value recorded as present")
+	}
+
+	// Operations built in code need only set Value
+	op := Operation{Op: "add", Path: "/a", Value: json.RawMessage(`1`)}
+	if _, err := applyOperation(map[string]interface{}{}, op); err != nil {
+		t.Errorf("applyOperation() error = %v", err)
+	}
+}
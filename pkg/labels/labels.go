@@ -0,0 +1,158 @@
+// Package labels implements key/value labels and equality-based label
+// selectors such as "env=prod,team!=payments,tier".
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Limits for label keys and values
+const (
+	MaxKeyLength   = 63
+	MaxValueLength = 63
+)
+
+var (
+	keyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	valuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+)
+
+// ErrInvalidSelector is returned for a selector that cannot be parsed
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+// ValidKey reports whether key can be used as a label key. Keys start and end
+// with a letter or digit and may contain '.', '_', '/' and '-' in between.
+func ValidKey(key string) bool {
+	return len(key) <= MaxKeyLength && keyPattern.MatchString(key)
+}
+
+// ValidValue reports whether value can be used as a label value. Values may
+// be empty and contain letters, digits, '.', '_' and '-'.
+func ValidValue(value string) bool {
+	return len(value) <= MaxValueLength && valuePattern.MatchString(value)
+}
+
+// Format returns labels as comma separated key=value pairs sorted by key
+func Format(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Operator is the comparison of a selector requirement
+type Operator string
+
+// Supported operators
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a single condition of a selector
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Value    string
+}
+
+// Matches reports whether labels satisfy the requirement. A missing label
+// satisfies a != requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case Equals:
+		return ok && value == r.Value
+	case NotEquals:
+		return !ok || value != r.Value
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// Selector is a list of requirements that must all be satisfied
+type Selector []Requirement
+
+// Matches reports whether labels satisfy all requirements. An empty selector
+// matches everything.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the selector in the syntax accepted by Parse
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		switch r.Operator {
+		case Exists:
+			parts = append(parts, r.Key)
+		case DoesNotExist:
+			parts = append(parts, "!"+r.Key)
+		default:
+			parts = append(parts, r.Key+string(r.Operator)+r.Value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse parses a comma separated list of requirements. Each requirement is
+// one of key=value (or key==value), key!=value, key (label exists) or !key
+// (label does not exist).
+func Parse(selector string) (Selector, error) {
+	var s Selector
+	if strings.TrimSpace(selector) == "" {
+		return s, nil
+	}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+
+		var r Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			r = Requirement{Key: strings.TrimSpace(key), Operator: NotEquals, Value: strings.TrimSpace(value)}
+		case strings.Contains(part, "=="):
+			key, value, _ := strings.Cut(part, "==")
+			r = Requirement{Key: strings.TrimSpace(key), Operator: Equals, Value: strings.TrimSpace(value)}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			r = Requirement{Key: strings.TrimSpace(key), Operator: Equals, Value: strings.TrimSpace(value)}
+		case strings.HasPrefix(part, "!"):
+			r = Requirement{Key: strings.TrimSpace(part[1:]), Operator: DoesNotExist}
+		default:
+			r = Requirement{Key: part, Operator: Exists}
+		}
+
+		if !ValidKey(r.Key) {
+			return nil, fmt.Errorf("%w: invalid key %q", ErrInvalidSelector, r.Key)
+		}
+		if !ValidValue(r.Value) {
+			return nil, fmt.Errorf("%w: invalid value %q", ErrInvalidSelector, r.Value)
+		}
+		s = append(s, r)
+	}
+
+	return s, nil
+}
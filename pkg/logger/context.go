@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+// contextFieldsKey is the context key of fields attached with ContextWithFields
+type contextFieldsKey struct{}
+
+// loggerKey is the context key of a logger stored with NewContext
+type loggerKey struct{}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = Default()
+)
+
+// ContextWithFields returns a copy of ctx carrying fields in addition to any
+// already attached. Loggers obtained through WithContext add them to every entry.
+func ContextWithFields(ctx context.Context, fields ...Field) context.Context {
+	existing := contextFields(ctx)
+	combined := make([]Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, contextFieldsKey{}, combined)
+}
+
+// contextFields returns the fields attached to ctx
+func contextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(contextFieldsKey{}).([]Field)
+	return fields
+}
+
+// NewContext returns a copy of ctx carrying l, for code that has a context
+// but no logger of its own
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx with NewContext, or the
+// default logger if there is none, with the fields attached to ctx added
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		defaultMu.RLock()
+		l = defaultLogger
+		defaultMu.RUnlock()
+	}
+	return l.WithContext(ctx)
+}
+
+// SetDefault sets the logger FromContext returns for contexts without one.
+// Until it is called, that is a JSON logger writing to stdout.
+func SetDefault(l Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Formats for LogConfig.Format and SinkConfig.Format
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatLogfmt = "logfmt"
+)
+
+// Timestamp formats for LogConfig.TimestampFormat
+const (
+	TimestampRFC3339     = "rfc3339"     // Whole seconds, e.g. 2006-01-02T15:04:05Z
+	TimestampRFC3339Nano = "rfc3339nano" // Up to nanoseconds, e.g. 2006-01-02T15:04:05.999999999Z
+)
+
+// formatter turns records into lines. Fields are always written in the same
+// order: timestamp, level and message first (for text and logfmt), then the
+// other fields sorted by key.
+type formatter struct {
+	format     string
+	timeLayout string
+}
+
+// newFormatter returns a formatter for a format and a timestamp format. An
+// empty format means text and an empty timestamp format means rfc3339.
+func newFormatter(format, timestampFormat string) (formatter, error) {
+	f := formatter{format: format, timeLayout: time.RFC3339}
+	switch format {
+	case "":
+		f.format = FormatText
+	case FormatJSON, FormatText, FormatLogfmt:
+	default:
+		return formatter{}, fmt.Errorf("unknown log format %q, must be one of %s, %s, %s", format, FormatJSON, FormatText, FormatLogfmt)
+	}
+
+	switch timestampFormat {
+	case "", TimestampRFC3339:
+	case TimestampRFC3339Nano:
+		f.timeLayout = time.RFC3339Nano
+	default:
+		return formatter{}, fmt.Errorf("unknown timestamp format %q, must be %s or %s", timestampFormat, TimestampRFC3339, TimestampRFC3339Nano)
+	}
+	return f, nil
+}
+
+// line returns the record as a line in the formatter's format
+func (f formatter) line(r *record) ([]byte, error) {
+	entry := f.entry(r)
+
+	var buf bytes.Buffer
+	switch f.format {
+	case FormatJSON:
+		// encoding/json writes map keys in sorted order
+		jsonEntry, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal log entry: %w", err)
+		}
+		buf.Write(jsonEntry)
+	case FormatLogfmt:
+		for i, k := range entryKeys(entry) {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			appendLogfmt(&buf, k, entry[k])
+		}
+	default:
+		// Simple text format, one field per line
+		fmt.Fprintf(&buf, "[%v] %v: %v", entry["timestamp"], entry["level"], entry["message"])
+		for _, k := range entryKeys(entry)[3:] {
+			fmt.Fprintf(&buf, "\n  %s: %v", k, entry[k])
+		}
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// entry returns the record as a map. Fields named level, message or
+// timestamp replace the record's own.
+func (f formatter) entry(r *record) map[string]interface{} {
+	entry := make(map[string]interface{}, len(r.fields)+3)
+	entry["level"] = r.label()
+	entry["message"] = r.message
+	entry["timestamp"] = r.time.Format(f.timeLayout)
+	for k, v := range r.fields {
+		entry[k] = v
+	}
+	return entry
+}
+
+// entryKeys returns timestamp, level and message followed by the other keys
+// of entry in sorted order
+func entryKeys(entry map[string]interface{}) []string {
+	keys := make([]string, 0, len(entry))
+	for k := range entry {
+		if k != "timestamp" && k != "level" && k != "message" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return append([]string{"timestamp", "level", "message"}, keys...)
+}
+
+// appendLogfmtFields appends fields as space-separated logfmt pairs sorted by key
+func appendLogfmtFields(buf *bytes.Buffer, fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		appendLogfmt(buf, k, fields[k])
+	}
+}
+
+// appendLogfmt appends key=value. Characters that would break the pair are
+// replaced in keys; values are quoted when they contain spaces, quotes,
+// equals signs or control characters.
+func appendLogfmt(buf *bytes.Buffer, key string, value interface{}) {
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+	if key == "" {
+		key = "_"
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+
+	s := logfmtValue(value)
+	if needsLogfmtQuotes(s) {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+// logfmtValue formats a value as a string. Values without a natural text
+// form, such as maps and slices, are written as JSON.
+func logfmtValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// needsLogfmtQuotes reports whether a value must be quoted
+func needsLogfmtQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
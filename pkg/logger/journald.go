@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultJournalAddress is the socket of the systemd journal native protocol
+const defaultJournalAddress = "/run/systemd/journal/socket"
+
+// journalReserved are the journal fields the writer sets itself; entry
+// fields with these names are written with a FIELD_ prefix instead
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// journaldWriter sends records to the systemd journal using its native
+// protocol, so entry fields become journal fields that journalctl can
+// filter on (journalctl REQUEST_ID=...). Field names are upper-cased and
+// characters other than letters, digits and underscores are replaced.
+type journaldWriter struct {
+	addr *net.UnixAddr
+	tag  string
+
+	// conn is not connected to the journal, so datagrams keep reaching it
+	// after a restart and descriptors can be passed along with them
+	conn *net.UnixConn
+}
+
+// newJournaldWriter opens a socket for sending to the journal at address
+func newJournaldWriter(address, tag string) (*journaldWriter, error) {
+	if address == "" {
+		address = defaultJournalAddress
+	}
+	if _, err := os.Stat(address); err != nil {
+		return nil, fmt.Errorf("journal socket not available: %w", err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal socket: %w", err)
+	}
+	return &journaldWriter{
+		addr: &net.UnixAddr{Name: address, Net: "unixgram"},
+		tag:  tag,
+		conn: conn,
+	}, nil
+}
+
+// write sends a record. Entries too large for a datagram are passed to the
+// journal in a temporary file, as the protocol provides for.
+func (w *journaldWriter) write(r *record) error {
+	payload := w.payload(r)
+
+	_, err := w.conn.WriteToUnix(payload, w.addr)
+	if err != nil && isMessageTooLarge(err) {
+		return sendJournalFile(w.conn, w.addr, payload)
+	}
+	return err
+}
+
+// payload encodes a record as journal fields
+func (w *journaldWriter) payload(r *record) []byte {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", r.message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(syslogSeverities[r.level]))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", w.tag)
+
+	for k, v := range r.fields {
+		name := journalFieldName(k)
+		if name == "" {
+			continue
+		}
+		if journalReserved[name] {
+			name = "FIELD_" + name
+		}
+		appendJournalField(&buf, name, journalValue(v))
+	}
+	return buf.Bytes()
+}
+
+// Close closes the socket
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+// appendJournalField encodes one field. Values containing newlines are
+// written with an explicit little-endian length instead of NAME=value.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName converts a field key to a valid journal field name:
+// upper case letters, digits and underscores, not starting with an
+// underscore or digit, at most 64 characters. It returns "" if nothing is left.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// journalValue formats a field value: strings as they are, other values
+// as JSON
+func journalValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
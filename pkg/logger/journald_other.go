@@ -0,0 +1,23 @@
+//go:build !unix
+
+package logger
+
+import (
+	"errors"
+	"net"
+)
+
+// isMessageTooLarge reports whether a datagram was rejected for its size
+func isMessageTooLarge(err error) bool {
+	return false
+}
+
+// sendJournalFile is not supported without unix sockets
+func sendJournalFile(conn *net.UnixConn, addr *net.UnixAddr, payload []byte) error {
+	return errors.New("journal entry too large")
+}
+
+// stdoutIsJournal is always false without systemd
+func stdoutIsJournal() bool {
+	return false
+}
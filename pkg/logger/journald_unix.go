@@ -0,0 +1,54 @@
+//go:build unix
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// isMessageTooLarge reports whether a datagram was rejected for its size
+func isMessageTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile passes payload to the journal through an unlinked
+// temporary file whose descriptor is sent over conn to addr
+func sendJournalFile(conn *net.UnixConn, addr *net.UnixAddr, payload []byte) error {
+	file, err := os.CreateTemp("/dev/shm", "journal-")
+	if err != nil {
+		file, err = os.CreateTemp("", "journal-")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry file: %w", err)
+	}
+	os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := file.Write(payload); err != nil {
+		return fmt.Errorf("failed to write journal entry file: %w", err)
+	}
+	_, _, err = conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), addr)
+	return err
+}
+
+// stdoutIsJournal reports whether systemd connected stdout to the journal,
+// which it announces in JOURNAL_STREAM as the device and inode of the stream
+func stdoutIsJournal() bool {
+	stream := os.Getenv("JOURNAL_STREAM")
+	if stream == "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return stream == fmt.Sprintf("%d:%d", stat.Dev, stat.Ino)
+}
@@ -1,12 +1,12 @@
 package logger
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,153 +26,129 @@ const (
 	FatalLevel
 )
 
-// Logger is the interface for logging
-type Logger interface {
-	Debug(msg string, fields ...Field)
-	Info(msg string, fields ...Field)
-	Warn(msg string, fields ...Field)
-	Error(msg string, fields ...Field)
-	Fatal(msg string, fields ...Field)
-	WithField(key string, value interface{}) Logger
-	Close() error
-}
+// levelNames are the names of levels in configuration and the admin API
+var levelNames = []string{"debug", "info", "warn", "error", "fatal"}
 
-// Field represents a key-value pair for structured logging
-type Field struct {
-	Key   string
-	Value interface{}
+// ParseLevel parses a level name (debug, info, warn, error, fatal)
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q, must be one of %s", name, strings.Join(levelNames, ", "))
 }
 
-// LogConfig represents configuration options for the logger
-type LogConfig struct {
-	Level      string `json:"level"`
-	Format     string `json:"format"`
-	FilePath   string `json:"file_path"`
-	MaxSize    int64  `json:"max_size"`    // Max size in MB
-	MaxBackups int    `json:"max_backups"` // Max number of rotated files to keep
-}
-
-// rotateWriter implements io.WriteCloser with log rotation capabilities
-type rotateWriter struct {
-	filePath   string
-	maxSize    int64 // in bytes
-	maxBackups int
-	size       int64
-	file       *os.File
-	mu         sync.Mutex
-}
-
-// newRotateWriter creates a new rotate writer
-func newRotateWriter(filePath string, maxSize int64, maxBackups int) (*rotateWriter, error) {
-	// Convert maxSize from MB to bytes
-	maxSize = maxSize * 1024 * 1024
-
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %w", err)
+// String returns the name of the level
+func (l Level) String() string {
+	if l < DebugLevel || l > FatalLevel {
+		return fmt.Sprintf("level(%d)", int(l))
 	}
+	return levelNames[l]
+}
 
-	// Open or create log file
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
+// MarshalText encodes the level as its name
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
 
-	// Get current file size
-	info, err := file.Stat()
+// UnmarshalText decodes a level from its name
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
 	if err != nil {
-		file.Close()
-		return nil, fmt.Errorf("failed to stat log file: %w", err)
+		return err
 	}
-
-	return &rotateWriter{
-		filePath:   filePath,
-		maxSize:    maxSize,
-		maxBackups: maxBackups,
-		size:       info.Size(),
-		file:       file,
-	}, nil
+	*l = level
+	return nil
 }
 
-// Write implements io.Writer
-func (w *rotateWriter) Write(p []byte) (n int, err error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	if w.size+int64(len(p)) > w.maxSize {
-		if err := w.rotate(); err != nil {
-			return 0, err
-		}
-	}
-
-	n, err = w.file.Write(p)
-	w.size += int64(n)
-	return n, err
+// levelVar holds a level that can be changed while it is being read. Sinks
+// with a level of their own use it only while the level is the configured
+// one; a level changed at runtime or set with WithLevel applies to all sinks.
+type levelVar struct {
+	v          atomic.Int32
+	configured Level
+	forced     bool
 }
 
-// Close implements io.Closer
-func (w *rotateWriter) Close() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	if w.file == nil {
-		return nil
-	}
-
-	err := w.file.Close()
-	w.file = nil
-	return err
+func newLevelVar(level Level) *levelVar {
+	lv := &levelVar{configured: level}
+	lv.set(level)
+	return lv
 }
 
-// rotate rotates the current log file
-func (w *rotateWriter) rotate() error {
-	// Close current file
-	if err := w.file.Close(); err != nil {
-		return fmt.Errorf("failed to close log file: %w", err)
-	}
-
-	// Rotate existing backup files
-	for i := w.maxBackups - 1; i > 0; i-- {
-		oldPath := fmt.Sprintf("%s.%d", w.filePath, i)
-		newPath := fmt.Sprintf("%s.%d", w.filePath, i+1)
-
-		// Remove the oldest backup if we're at max
-		if i == w.maxBackups-1 {
-			os.Remove(newPath)
-		}
+// overridden reports whether the level replaces the levels of sinks
+func (lv *levelVar) overridden() bool {
+	return lv.forced || lv.get() != lv.configured
+}
 
-		// Rename the backups
-		if _, err := os.Stat(oldPath); err == nil {
-			os.Rename(oldPath, newPath)
-		}
-	}
+func (lv *levelVar) get() Level {
+	return Level(lv.v.Load())
+}
 
-	// Rename current log file to .1
-	if err := os.Rename(w.filePath, w.filePath+".1"); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to rename log file: %w", err)
-	}
+func (lv *levelVar) set(level Level) {
+	lv.v.Store(int32(level))
+}
 
-	// Create new log file
-	file, err := os.OpenFile(w.filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to create new log file: %w", err)
-	}
+// Logger is the interface for logging
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	Fatal(msg string, fields ...Field)
+	WithField(key string, value interface{}) Logger
+	// WithFields returns a logger that adds fields to every entry
+	WithFields(fields ...Field) Logger
+	// WithContext returns a logger that adds the fields attached to ctx with
+	// ContextWithFields, such as the ID of the request being served
+	WithContext(ctx context.Context) Logger
+	// Enabled reports whether entries of the given level are written
+	Enabled(level Level) bool
+	// Level returns the minimum level of entries written
+	Level() Level
+	// SetLevel changes the minimum level of the logger and of all loggers
+	// derived from it with With* methods, while they are in use
+	SetLevel(level Level)
+	// WithLevel returns a logger with its own minimum level that SetLevel
+	// does not affect, for example to debug a single component
+	WithLevel(level Level) Logger
+	// Reopen reopens the log file, for use after it was moved by an
+	// external tool such as logrotate. It does nothing for other outputs.
+	Reopen() error
+	Close() error
+}
 
-	// Reset size and update file
-	w.size = 0
-	w.file = file
+// Field represents a key-value pair for structured logging
+type Field struct {
+	Key   string
+	Value interface{}
+}
 
-	return nil
+// LogConfig represents configuration options for the logger. Without sinks,
+// entries are written to the file at FilePath or, if it is empty, to stdout
+// (or to the journal when systemd connected stdout to it).
+type LogConfig struct {
+	Level           string       `json:"level"`
+	Format          string       `json:"format"` // "json", "text" or "logfmt"
+	FilePath        string       `json:"file_path"`
+	MaxSize         int64        `json:"max_size"`         // Max size in MB
+	MaxBackups      int          `json:"max_backups"`      // Max number of rotated files to keep
+	MaxAgeDays      int          `json:"max_age_days"`     // Remove rotated files older than this many days (0 keeps them)
+	Compress        bool         `json:"compress"`         // Gzip rotated files
+	RotateEvery     string       `json:"rotate_every"`     // Also rotate at the start of each period: "daily", "hourly" or "" for size only
+	TimestampFormat string       `json:"timestamp_format"` // "rfc3339" (whole seconds, default) or "rfc3339nano"
+	Sinks           []SinkConfig `json:"sinks"`            // Destinations, replacing FilePath and stdout
+	Tag             string       `json:"tag"`              // Program name in syslog and journald entries (default: executable name)
+	Redact          RedactConfig `json:"redact"`           // Secrets removed from entries in addition to the built-in ones
 }
 
 // logger implements the Logger interface
 type logger struct {
-	level  Level
-	format string
-	output io.Writer
-	fields map[string]interface{}
-	closer io.Closer
+	level    *levelVar // shared with derived loggers
+	sinks    sinks     // shared with derived loggers
+	redactor *redactor
+	fields   map[string]interface{}
 }
 
 // cleanupFileWriter gets a closer from a writer if it's a closable resource
@@ -187,51 +163,79 @@ func cleanupFileWriter(writer io.Writer) io.Closer {
 func NewWithConfig(config LogConfig) (Logger, error) {
 	lvl := parseLevel(config.Level)
 
-	var writer io.Writer
-	var closer io.Closer
+	redactor, err := newRedactor(config.Redact)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use file if path is provided, otherwise use stdout
-	if config.FilePath != "" {
-		// Default values if not specified
-		maxSize := config.MaxSize
-		if maxSize <= 0 {
-			maxSize = 100 // Default 100MB
-		}
+	tag := config.Tag
+	if tag == "" {
+		tag = defaultTag()
+	}
 
-		maxBackups := config.MaxBackups
-		if maxBackups <= 0 {
-			maxBackups = 5 // Default 5 backups
-		}
+	sinkConfigs := config.Sinks
+	if len(sinkConfigs) == 0 {
+		sinkConfigs = []SinkConfig{defaultSink(config)}
+	}
 
-		fileWriter, err := newRotateWriter(config.FilePath, maxSize, maxBackups)
+	var ss sinks
+	for _, sc := range sinkConfigs {
+		s, err := newSink(sc, config.Format, config.TimestampFormat, tag)
+		if err != nil && len(config.Sinks) == 0 && sc.Type == SinkJournald {
+			// stdout still reaches the journal, only without structured fields
+			s, err = newSink(SinkConfig{Type: SinkStdout}, config.Format, config.TimestampFormat, tag)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to create log writer: %w", err)
+			ss.close()
+			return nil, err
 		}
-
-		writer = fileWriter
-		closer = fileWriter
-	} else {
-		writer = os.Stdout
+		ss = append(ss, s)
 	}
 
 	return &logger{
-		level:  lvl,
-		format: config.Format,
-		output: writer,
-		fields: make(map[string]interface{}),
-		closer: closer,
+		level:    newLevelVar(lvl),
+		sinks:    ss,
+		redactor: redactor,
+		fields:   make(map[string]interface{}),
 	}, nil
 }
 
-// New creates a new logger
+// defaultSink returns the sink used when none are configured: the file at
+// FilePath, else the journal if stdout is connected to it, else stdout
+func defaultSink(config LogConfig) SinkConfig {
+	switch {
+	case config.FilePath != "":
+		return SinkConfig{
+			Type:        SinkFile,
+			FilePath:    config.FilePath,
+			MaxSize:     config.MaxSize,
+			MaxBackups:  config.MaxBackups,
+			MaxAgeDays:  config.MaxAgeDays,
+			Compress:    config.Compress,
+			RotateEvery: config.RotateEvery,
+		}
+	case stdoutIsJournal():
+		return SinkConfig{Type: SinkJournald}
+	default:
+		return SinkConfig{Type: SinkStdout}
+	}
+}
+
+// New creates a new logger. Unknown formats are written as text.
 func New(level string, format string, output io.Writer) Logger {
 	lvl := parseLevel(level)
+	f, err := newFormatter(format, TimestampRFC3339)
+	if err != nil {
+		f, _ = newFormatter(FormatText, TimestampRFC3339)
+	}
 	return &logger{
-		level:  lvl,
-		format: format,
-		output: output,
-		fields: make(map[string]interface{}),
-		closer: cleanupFileWriter(output),
+		level: newLevelVar(lvl),
+		sinks: sinks{{
+			name:   "log output",
+			writer: &streamWriter{formatter: f, out: output, closer: cleanupFileWriter(output)},
+		}},
+		redactor: defaultRedactor,
+		fields:   make(map[string]interface{}),
 	}
 }
 
@@ -240,116 +244,136 @@ func Default() Logger {
 	return New("info", "json", os.Stdout)
 }
 
-// parseLevel parses the level string
+// parseLevel parses the level string, defaulting to info
 func parseLevel(level string) Level {
-	switch level {
-	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn":
-		return WarnLevel
-	case "error":
-		return ErrorLevel
-	case "fatal":
-		return FatalLevel
-	default:
+	lvl, err := ParseLevel(level)
+	if err != nil {
 		return InfoLevel
 	}
+	return lvl
 }
 
 // Debug logs a debug message
 func (l *logger) Debug(msg string, fields ...Field) {
-	if l.level <= DebugLevel {
-		l.log("DEBUG", msg, fields...)
+	if l.Enabled(DebugLevel) {
+		l.log(DebugLevel, msg, fields...)
 	}
 }
 
 // Info logs an info message
 func (l *logger) Info(msg string, fields ...Field) {
-	if l.level <= InfoLevel {
-		l.log("INFO", msg, fields...)
+	if l.Enabled(InfoLevel) {
+		l.log(InfoLevel, msg, fields...)
 	}
 }
 
 // Warn logs a warning message
 func (l *logger) Warn(msg string, fields ...Field) {
-	if l.level <= WarnLevel {
-		l.log("WARN", msg, fields...)
+	if l.Enabled(WarnLevel) {
+		l.log(WarnLevel, msg, fields...)
 	}
 }
 
 // Error logs an error message
 func (l *logger) Error(msg string, fields ...Field) {
-	if l.level <= ErrorLevel {
-		l.log("ERROR", msg, fields...)
+	if l.Enabled(ErrorLevel) {
+		l.log(ErrorLevel, msg, fields...)
 	}
 }
 
 // Fatal logs a fatal message and exits
 func (l *logger) Fatal(msg string, fields ...Field) {
-	if l.level <= FatalLevel {
-		l.log("FATAL", msg, fields...)
+	if l.Enabled(FatalLevel) {
+		l.log(FatalLevel, msg, fields...)
 		os.Exit(1)
 	}
 }
 
 // WithField returns a new logger with the field added
 func (l *logger) WithField(key string, value interface{}) Logger {
+	return l.WithFields(Field{Key: key, Value: value})
+}
+
+// WithFields returns a new logger with the fields added
+func (l *logger) WithFields(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
 	newLogger := &logger{
-		level:  l.level,
-		format: l.format,
-		output: l.output,
-		fields: make(map[string]interface{}, len(l.fields)+1),
-		closer: l.closer,
+		level:    l.level,
+		sinks:    l.sinks,
+		redactor: l.redactor,
+		fields:   make(map[string]interface{}, len(l.fields)+len(fields)),
 	}
 	for k, v := range l.fields {
 		newLogger.fields[k] = v
 	}
-	newLogger.fields[key] = value
+	for _, f := range fields {
+		newLogger.fields[f.Key] = f.Value
+	}
 	return newLogger
 }
 
+// WithContext returns a new logger with the fields attached to ctx added
+func (l *logger) WithContext(ctx context.Context) Logger {
+	return l.WithFields(contextFields(ctx)...)
+}
+
+// Enabled reports whether any sink writes entries of the given level
+func (l *logger) Enabled(level Level) bool {
+	return l.sinks.enabled(level, l.level)
+}
+
+// Level returns the minimum level of entries written
+func (l *logger) Level() Level {
+	return l.level.get()
+}
+
+// SetLevel changes the minimum level of the logger and its derived loggers
+func (l *logger) SetLevel(level Level) {
+	l.level.set(level)
+}
+
+// WithLevel returns a new logger with its own minimum level
+func (l *logger) WithLevel(level Level) Logger {
+	newLogger := *l
+	newLogger.level = newLevelVar(level)
+	newLogger.level.forced = true
+	return &newLogger
+}
+
+// Reopen reopens the log files the logger writes to
+func (l *logger) Reopen() error {
+	return l.sinks.reopen()
+}
+
 // Close implements io.Closer for cleaning up resources
 func (l *logger) Close() error {
-	if l.closer != nil {
-		return l.closer.Close()
-	}
-	return nil
+	return l.sinks.close()
 }
 
-// log logs a message with the specified level
-func (l *logger) log(level string, msg string, fields ...Field) {
-	entry := map[string]interface{}{
-		"level":     level,
-		"message":   msg,
-		"timestamp": time.Now().Format(time.RFC3339),
+// log passes an entry to the sinks
+func (l *logger) log(level Level, msg string, fields ...Field) {
+	entry := &record{
+		time:    time.Now(),
+		level:   level,
+		message: msg,
+		fields:  make(map[string]interface{}, len(l.fields)+len(fields)),
 	}
 
 	// Add default fields
 	for k, v := range l.fields {
-		entry[k] = v
+		entry.fields[k] = v
 	}
 
 	// Add fields
 	for _, f := range fields {
-		entry[f.Key] = f.Value
+		entry.fields[f.Key] = f.Value
 	}
 
-	if l.format == "json" {
-		jsonEntry, err := json.Marshal(entry)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to marshal log entry: %v\n", err)
-			return
-		}
-		fmt.Fprintln(l.output, string(jsonEntry))
-	} else {
-		// Simple text format
-		fmt.Fprintf(l.output, "[%s] %s: %s\n", entry["timestamp"], level, msg)
-		for k, v := range entry {
-			if k != "timestamp" && k != "level" && k != "message" {
-				fmt.Fprintf(l.output, "  %s: %v\n", k, v)
-			}
-		}
-	}
+	// Remove secrets before any sink sees the entry
+	l.redactor.record(entry)
+
+	l.sinks.write(entry, l.level)
 }
@@ -0,0 +1,248 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces secrets in log entries
+const Redacted = "[REDACTED]"
+
+// RedactConfig adds to the secrets removed from log entries. The built-in
+// keys and patterns always apply.
+type RedactConfig struct {
+	// Keys are field names whose values are replaced, compared without regard
+	// to case, "-" and "_". A field also matches if its name ends in _<key>,
+	// so "token" covers "admin_token".
+	Keys []string `json:"keys"`
+	// Patterns are regular expressions matched against messages and string
+	// values. The first capturing group is replaced, or the whole match if
+	// there is none. Values of the keys in key=value pairs and JSON members
+	// ("key": "value") are always found, so keys need no pattern of their own.
+	Patterns []string `json:"patterns"`
+}
+
+// defaultRedactKeys are field names that always hold secrets
+var defaultRedactKeys = []string{
+	"token", "access_token", "refresh_token", "password", "passwd", "secret",
+	"client_secret", "api_key", "apikey", "authorization", "proxy_authorization",
+	"cookie", "set_cookie", "private_key",
+}
+
+// defaultRedactPatterns find secrets inside longer strings, such as an
+// Authorization header copied into a message. Patterns for the values of
+// secret keys are added by keyPatterns.
+var defaultRedactPatterns = []string{
+	`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9._~+/-]{8,}=*)`,
+}
+
+// keyPatterns returns patterns that find the values of keys in key=value
+// pairs, as in a logged URL or form body, and in JSON string members, as in
+// a logged request body. Like secretKey, they match keys regardless of case
+// and "-" or "_", and keys ending in _<key>.
+func keyPatterns(keys []string) []string {
+	alternatives := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = regexp.QuoteMeta(normalizeRedactKey(key))
+		alternatives = append(alternatives, strings.ReplaceAll(key, "_", "[_-]"))
+	}
+	// Longer keys first, so that the longest match wins
+	sort.Slice(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+	names := `(?:[\w-]*[_-])?(?:` + strings.Join(alternatives, "|") + `)`
+
+	return []string{
+		`(?i)\b` + names + `=([^&\s"]+)`,
+		`(?i)"` + names + `"\s*:\s*"((?:[^"\\]|\\.)*)"`,
+	}
+}
+
+// redactor removes secrets from records before they reach the sinks
+type redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// newRedactor returns a redactor for the built-in keys and patterns and
+// those in config
+func newRedactor(config RedactConfig) (*redactor, error) {
+	r := &redactor{keys: make(map[string]bool)}
+	keys := append(append([]string(nil), defaultRedactKeys...), config.Keys...)
+	for _, key := range keys {
+		r.keys[normalizeRedactKey(key)] = true
+	}
+	patterns := append(keyPatterns(keys), defaultRedactPatterns...)
+	for _, pattern := range append(patterns, config.Patterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// defaultRedactor uses only the built-in keys and patterns, which compile
+var defaultRedactor, _ = newRedactor(RedactConfig{})
+
+// normalizeRedactKey lower-cases a key and treats "-" like "_", so header
+// names and field names compare alike
+func normalizeRedactKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+// secretKey reports whether values of a field with this name are secrets
+func (r *redactor) secretKey(key string) bool {
+	key = normalizeRedactKey(key)
+	if r.keys[key] {
+		return true
+	}
+	for k := range r.keys {
+		if strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return false
+}
+
+// record removes secrets from the message and fields of a record
+func (r *redactor) record(rec *record) {
+	rec.message = r.string(rec.message)
+	for k, v := range rec.fields {
+		if r.secretKey(k) {
+			rec.fields[k] = Redacted
+			continue
+		}
+		rec.fields[k] = r.value(v)
+	}
+}
+
+// value removes secrets from a field value. Strings and errors are matched
+// against the patterns; maps keyed by strings, such as headers, are redacted
+// by key and value. Other values are returned unchanged.
+func (r *redactor) value(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		return r.string(value)
+	case Query:
+		return r.query(url.Values(value))
+	case error:
+		return r.string(value.Error())
+	case []string:
+		redacted := make([]string, len(value))
+		for i, s := range value {
+			redacted[i] = r.string(s)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(value))
+		for k, s := range value {
+			if r.secretKey(k) {
+				redacted[k] = Redacted
+			} else {
+				redacted[k] = r.string(s)
+			}
+		}
+		return redacted
+	case map[string][]string:
+		redacted := make(map[string][]string, len(value))
+		for k, s := range value {
+			if r.secretKey(k) {
+				redacted[k] = []string{Redacted}
+			} else {
+				redacted[k] = r.value(s).([]string)
+			}
+		}
+		return redacted
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for k, s := range value {
+			if r.secretKey(k) {
+				redacted[k] = Redacted
+			} else {
+				redacted[k] = r.value(s)
+			}
+		}
+		return redacted
+	}
+	return v
+}
+
+// string replaces the secrets the patterns find in s
+func (r *redactor) string(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, Redacted)
+			continue
+		}
+
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if matches == nil {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[2], m[3]
+			if start < 0 {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Redacted)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// Query is a field value for a query string. Loggers encode it with the
+// values of parameters that hold secrets, such as token or any of the
+// configured keys, replaced by [REDACTED], and parameters sorted by name.
+type Query url.Values
+
+// String encodes the query with the built-in secret keys redacted
+func (q Query) String() string {
+	return defaultRedactor.query(url.Values(q))
+}
+
+// MarshalJSON encodes the query as a JSON string, like String
+func (q Query) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// LogValue encodes the query for log/slog handlers, like String
+func (q Query) LogValue() slog.Value {
+	return slog.StringValue(q.String())
+}
+
+// query encodes a query string with the secrets in it redacted
+func (r *redactor) query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range query[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			b.WriteByte('=')
+			if r.secretKey(k) {
+				b.WriteString(Redacted)
+			} else {
+				b.WriteString(url.QueryEscape(r.string(v)))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestRedactorString(t *testing.T) {
+	r, err := newRedactor(RedactConfig{
+		Keys:     []string{"signature"},
+		Patterns: []string{`sk_live_[0-9a-z]+`, `card=(\d+)`},
+	})
+	if err != nil {
+		t.Fatalf("newRedactor() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no secrets", "hook deploy triggered", "hook deploy triggered"},
+		{"query parameter", "/webhook/deploy?token=abc123&x=1", "/webhook/deploy?token=[REDACTED]&x=1"},
+		{"suffixed key", "admin_token=abc123", "admin_token=[REDACTED]"},
+		{"dashed key", "api-key=abc123", "api-key=[REDACTED]"},
+		{"key case", "PASSWORD=hunter2", "PASSWORD=[REDACTED]"},
+		{"configured key", "a=1&signature=abcdef", "a=1&signature=[REDACTED]"},
+		{"key inside a word", "mytoken=abc123", "mytoken=abc123"},
+		{"bearer credentials", "Authorization: Bearer abcdefgh12345", "Authorization: Bearer [REDACTED]"},
+		{"short bearer", "Bearer abc", "Bearer abc"},
+		{"json member", `{"token":"abc123","name":"deploy"}`, `{"token":"[REDACTED]","name":"deploy"}`},
+		{"json member with spaces", `{"password" : "hunter2"}`, `{"password" : "[REDACTED]"}`},
+		{"json suffixed key", `{"client_secret":"s3cr3t"}`, `{"client_secret":"[REDACTED]"}`},
+		{"json escaped quote", `{"password":"a\"b","x":"y"}`, `{"password":"[REDACTED]","x":"y"}`},
+		{"json configured key", `{"Signature": "abcdef"}`, `{"Signature": "[REDACTED]"}`},
+		{"json non-secret member", `{"tokens_used":"5"}`, `{"tokens_used":"5"}`},
+		{"pattern without group", "key sk_live_abc123 used", "key [REDACTED] used"},
+		{"pattern with group", "card=4111111111111111", "card=[REDACTED]"},
+		{"several secrets", "token=a1&password=b2", "token=[REDACTED]&password=[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.string(tt.in); got != tt.want {
+				t.Errorf("string(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorValue(t *testing.T) {
+	r, err := newRedactor(RedactConfig{Keys: []string{"signature"}})
+	if err != nil {
+		t.Fatalf("newRedactor() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"string", "token=abc123", "token=[REDACTED]"},
+		{"error", errors.New("bad password=hunter2"), "bad password=[REDACTED]"},
+		{"number", 42, 42},
+		{"string slice", []string{"a", "secret=x1"}, []string{"a", "secret=[REDACTED]"}},
+		{
+			"headers",
+			map[string][]string{"Authorization": {"Bearer abcdefgh12345"}, "X-Signature": {"abc"}, "Accept": {"*/*"}},
+			map[string][]string{"Authorization": {Redacted}, "X-Signature": {Redacted}, "Accept": {"*/*"}},
+		},
+		{
+			"string map",
+			map[string]string{"password": "hunter2", "user": "bob"},
+			map[string]string{"password": Redacted, "user": "bob"},
+		},
+		{
+			"nested map",
+			map[string]interface{}{"auth": map[string]interface{}{"token": "abc"}, "body": `{"token":"abc"}`},
+			map[string]interface{}{"auth": map[string]interface{}{"token": Redacted}, "body": `{"token":"[REDACTED]"}`},
+		},
+		{
+			"query",
+			Query(url.Values{"token": {"abc"}, "signature": {"def"}, "b": {"x y"}, "a": {"1"}}),
+			"a=1&b=x+y&signature=[REDACTED]&token=[REDACTED]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.value(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("value(%v) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorRecord(t *testing.T) {
+	r, err := newRedactor(RedactConfig{Keys: []string{"signature"}})
+	if err != nil {
+		t.Fatalf("newRedactor() error = %v", err)
+	}
+
+	rec := &record{
+		message: "login with password=hunter2",
+		fields:  map[string]interface{}{"admin_token": "abc", "x-signature": "def", "path": "/hooks"},
+	}
+	r.record(rec)
+
+	if rec.message != "login with password=[REDACTED]" {
+		t.Errorf("message = %q", rec.message)
+	}
+	want := map[string]interface{}{"admin_token": Redacted, "x-signature": Redacted, "path": "/hooks"}
+	if !reflect.DeepEqual(rec.fields, want) {
+		t.Errorf("fields = %v, want %v", rec.fields, want)
+	}
+}
+
+func TestQueryDefaultEncoding(t *testing.T) {
+	q := Query(url.Values{"token": {"abc"}, "signature": {"def"}})
+
+	// Without a configured redactor only the built-in keys are redacted
+	if got, want := q.String(), "signature=def&token=[REDACTED]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("MarshalJSON() = %s is not a JSON string", data)
+	}
+	if want := "signature=def&token=[REDACTED]"; got != want {
+		t.Errorf("MarshalJSON() = %q, want %q", got, want)
+	}
+}
+
+func TestNewRedactorInvalidPattern(t *testing.T) {
+	if _, err := newRedactor(RedactConfig{Patterns: []string{"("}}); err == nil {
+		t.Error("newRedactor() accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,418 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rotation periods for LogConfig.RotateEvery
+const (
+	RotateDaily  = "daily"
+	RotateHourly = "hourly"
+)
+
+// rotateOptions configures a rotateWriter
+type rotateOptions struct {
+	filePath    string
+	maxSize     int64 // in MB
+	maxBackups  int
+	maxAgeDays  int
+	compress    bool
+	rotateEvery string
+}
+
+// rotateWriter implements io.WriteCloser with log rotation capabilities.
+// Files are rotated when they reach the maximum size and, if a period is set,
+// at the start of each day or hour. Size-only rotation keeps numbered backups
+// (.1 is the newest); periodic rotation names backups after the period they
+// cover (.2006-01-02 or .2006-01-02T15). On rotation the file is only renamed;
+// numbering, compression and pruning are left to a single background worker,
+// so writes are never held up by them.
+type rotateWriter struct {
+	filePath    string
+	maxSize     int64 // in bytes
+	maxBackups  int
+	maxAge      time.Duration
+	compress    bool
+	rotateEvery string
+	size        int64
+	file        *os.File
+	periodStart time.Time // start of the period the current file covers
+	closed      bool
+	mu          sync.Mutex
+
+	// jobs queues rotated files for the worker, which closes done when
+	// jobs is closed and all queued files are handled
+	jobs chan rotatedFile
+	done chan struct{}
+}
+
+// rotatedFile is a file renamed away from the log path, waiting for the worker
+type rotatedFile struct {
+	path string
+	// numbered files are moved to .1 once older backups have been shifted
+	numbered bool
+}
+
+// maxQueuedRotations limits the rotated files waiting for the worker; writes
+// block only if this many rotations are pending
+const maxQueuedRotations = 64
+
+// newRotateWriter creates a new rotate writer
+func newRotateWriter(opts rotateOptions) (*rotateWriter, error) {
+	if opts.rotateEvery != "" && opts.rotateEvery != RotateDaily && opts.rotateEvery != RotateHourly {
+		return nil, fmt.Errorf("unknown rotation period %q, must be %s or %s", opts.rotateEvery, RotateDaily, RotateHourly)
+	}
+
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(opts.filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	w := &rotateWriter{
+		filePath:    opts.filePath,
+		maxSize:     opts.maxSize * 1024 * 1024, // Convert maxSize from MB to bytes
+		maxBackups:  opts.maxBackups,
+		maxAge:      time.Duration(opts.maxAgeDays) * 24 * time.Hour,
+		compress:    opts.compress,
+		rotateEvery: opts.rotateEvery,
+		jobs:        make(chan rotatedFile, maxQueuedRotations),
+		done:        make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	go w.work()
+
+	// Finish rotations interrupted by a crash or restart
+	for _, path := range w.pendingRotations() {
+		w.jobs <- rotatedFile{path: path, numbered: true}
+	}
+	return w, nil
+}
+
+// open opens or creates the log file and records its size and period
+func (w *rotateWriter) open() error {
+	file, err := os.OpenFile(w.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	// Get current file size
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+
+	// An existing file belongs to the period it was last written in, so a
+	// file left over from yesterday is rotated on the first write today
+	w.periodStart = w.periodOf(time.Now())
+	if info.Size() > 0 {
+		w.periodStart = w.periodOf(info.ModTime())
+	}
+	return nil
+}
+
+// periodOf returns the start of the rotation period containing t
+func (w *rotateWriter) periodOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch w.rotateEvery {
+	case RotateDaily:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	case RotateHourly:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// Write implements io.Writer
+func (w *rotateWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	newPeriod := w.rotateEvery != "" && w.periodOf(time.Now()).After(w.periodStart)
+	if newPeriod || w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Reopen closes and reopens the log file. Call it after an external tool
+// such as logrotate has moved the file away, so writing continues in a new
+// file at the configured path.
+func (w *rotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		w.file = nil
+	}
+	return w.open()
+}
+
+// Close implements io.Closer. It waits for rotated files to be compressed.
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	close(w.jobs)
+	w.mu.Unlock()
+
+	<-w.done
+	return err
+}
+
+// rotate moves the current log file aside and starts a new one. Periodic
+// backups get their final name at once; size-based backups get a temporary
+// name, as the worker may still be shifting older backups.
+func (w *rotateWriter) rotate() error {
+	// Close current file
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	job := rotatedFile{numbered: w.rotateEvery == ""}
+	if job.numbered {
+		job.path = fmt.Sprintf("%s.rotating-%020d", w.filePath, time.Now().UnixNano())
+	} else {
+		job.path = w.periodBackupName()
+	}
+
+	// Rename current log file to the backup
+	renamed := true
+	if err := os.Rename(w.filePath, job.path); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rename log file: %w", err)
+		}
+		renamed = false
+	}
+
+	// Create new log file
+	file, err := os.OpenFile(w.filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create new log file: %w", err)
+	}
+
+	// Reset size and update file
+	w.size = 0
+	w.file = file
+	w.periodStart = w.periodOf(time.Now())
+
+	if renamed {
+		w.jobs <- job
+	}
+	return nil
+}
+
+// work numbers, compresses and prunes rotated files in the order they were
+// rotated, until jobs is closed
+func (w *rotateWriter) work() {
+	defer close(w.done)
+	for job := range w.jobs {
+		backup := job.path
+		if job.numbered {
+			w.shiftBackups()
+			backup = w.filePath + ".1"
+			if err := os.Rename(job.path, backup); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to rename rotated log file %s: %v\n", job.path, err)
+				continue
+			}
+		}
+		if w.compress {
+			compressFile(backup)
+		}
+		w.prune()
+	}
+}
+
+// pendingRotations returns files left with a temporary rotation name, oldest first
+func (w *rotateWriter) pendingRotations() []string {
+	matches, err := filepath.Glob(w.filePath + ".rotating-*")
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// shiftBackups renames numbered backups up by one, dropping the oldest
+func (w *rotateWriter) shiftBackups() {
+	for i := w.maxBackups - 1; i > 0; i-- {
+		for _, ext := range []string{"", ".gz"} {
+			oldPath := fmt.Sprintf("%s.%d%s", w.filePath, i, ext)
+			newPath := fmt.Sprintf("%s.%d%s", w.filePath, i+1, ext)
+
+			// Remove the oldest backup if we're at max
+			if i == w.maxBackups-1 {
+				os.Remove(newPath)
+			}
+
+			// Rename the backups
+			if _, err := os.Stat(oldPath); err == nil {
+				os.Rename(oldPath, newPath)
+			}
+		}
+	}
+}
+
+// periodBackupName returns the backup name for the current period. If the file
+// was already rotated by size in this period, the name gets a numeric suffix
+// above any used so far, so suffixes keep their order after pruning.
+func (w *rotateWriter) periodBackupName() string {
+	layout := "2006-01-02"
+	if w.rotateEvery == RotateHourly {
+		layout = "2006-01-02T15"
+	}
+	base := w.filePath + "." + w.periodStart.Format(layout)
+
+	used := fileExists(base) || fileExists(base+".gz")
+	last := 0
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(filepath.Base(base)) + `\.(\d+)(\.gz)?$`)
+	if entries, err := os.ReadDir(filepath.Dir(base)); err == nil {
+		for _, entry := range entries {
+			if m := pattern.FindStringSubmatch(entry.Name()); m != nil {
+				used = true
+				if n, err := strconv.Atoi(m[1]); err == nil && n > last {
+					last = n
+				}
+			}
+		}
+	}
+
+	if !used {
+		return base
+	}
+	return base + "." + strconv.Itoa(last+1)
+}
+
+// prune removes backups beyond the maximum count and older than the maximum age
+func (w *rotateWriter) prune() {
+	dir := filepath.Dir(w.filePath)
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(filepath.Base(w.filePath)) +
+		`\.(\d+|\d{4}-\d{2}-\d{2}(T\d{2})?(\.\d+)?)(\.gz)?$`)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list log backups: %v\n", err)
+		return
+	}
+
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		if !pattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, entry.Name()), modTime: info.ModTime()})
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool { return backups[i].modTime.After(backups[j].modTime) })
+
+	cutoff := time.Now().Add(-w.maxAge)
+	for i, b := range backups {
+		if i >= w.maxBackups || (w.maxAge > 0 && b.modTime.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// compressFile replaces a file with a gzip-compressed copy that keeps its
+// modification time, so age-based pruning still sees when it was written
+func compressFile(path string) {
+	if err := gzipFile(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compress log file %s: %v\n", path, err)
+	}
+}
+
+// gzipFile writes path.gz through a temporary file and removes path
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
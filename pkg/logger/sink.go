@@ -0,0 +1,254 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Sink types for SinkConfig.Type
+const (
+	SinkFile     = "file"
+	SinkStdout   = "stdout"
+	SinkStderr   = "stderr"
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+)
+
+// SinkConfig configures one destination of log entries
+type SinkConfig struct {
+	Type   string `json:"type"`   // "file", "stdout", "stderr", "syslog" or "journald"
+	Level  string `json:"level"`  // Minimum level of this sink until the level is changed at runtime (if empty, follows the logger level)
+	Format string `json:"format"` // "json", "text" or "logfmt" (if empty, the logger format); journald entries are always structured
+
+	// File sinks
+	FilePath    string `json:"file_path"`
+	MaxSize     int64  `json:"max_size"`     // Max size in MB
+	MaxBackups  int    `json:"max_backups"`  // Max number of rotated files to keep
+	MaxAgeDays  int    `json:"max_age_days"` // Remove rotated files older than this many days (0 keeps them)
+	Compress    bool   `json:"compress"`     // Gzip rotated files
+	RotateEvery string `json:"rotate_every"` // Also rotate at the start of each period: "daily", "hourly" or "" for size only
+
+	// Syslog and journald sinks
+	Address  string `json:"address"`  // Unix socket (default /dev/log for syslog, /run/systemd/journal/socket for journald)
+	Facility string `json:"facility"` // Syslog facility, e.g. "daemon" (default) or "local0"
+}
+
+// record is a log entry as passed to sinks
+type record struct {
+	time    time.Time
+	level   Level
+	message string
+	fields  map[string]interface{}
+}
+
+// label returns the level as written in entries, e.g. "INFO"
+func (r *record) label() string {
+	return levelLabels[r.level]
+}
+
+// levelLabels are the names of levels in log entries
+var levelLabels = map[Level]string{
+	DebugLevel: "DEBUG",
+	InfoLevel:  "INFO",
+	WarnLevel:  "WARN",
+	ErrorLevel: "ERROR",
+	FatalLevel: "FATAL",
+}
+
+// sinkWriter writes records to a destination
+type sinkWriter interface {
+	write(r *record) error
+	io.Closer
+}
+
+// sink is a destination with its own minimum level
+type sink struct {
+	name   string // used in error messages
+	level  *Level // nil to follow the logger level
+	writer sinkWriter
+}
+
+// enabled reports whether the sink writes entries of level for a logger
+// whose level is loggerLevel. The sink's own level applies only while the
+// logger level is the configured one, so that changing the level at runtime
+// and debug dumps of selected hooks reach every sink.
+func (s *sink) enabled(level Level, loggerLevel *levelVar) bool {
+	if s.level != nil && !loggerLevel.overridden() {
+		return *s.level <= level
+	}
+	return loggerLevel.get() <= level
+}
+
+// sinks are the destinations shared by a logger and the loggers derived from it
+type sinks []*sink
+
+// write passes a record to every sink that accepts its level. A sink that
+// fails does not keep the others from writing.
+func (ss sinks) write(r *record, loggerLevel *levelVar) {
+	for _, s := range ss {
+		if !s.enabled(r.level, loggerLevel) {
+			continue
+		}
+		if err := s.writer.write(r); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write log entry to %s: %v\n", s.name, err)
+		}
+	}
+}
+
+// enabled reports whether any sink writes entries of level
+func (ss sinks) enabled(level Level, loggerLevel *levelVar) bool {
+	for _, s := range ss {
+		if s.enabled(level, loggerLevel) {
+			return true
+		}
+	}
+	return false
+}
+
+// reopen reopens the sinks that write to files
+func (ss sinks) reopen() error {
+	var errs []error
+	for _, s := range ss {
+		if r, ok := s.writer.(interface{ Reopen() error }); ok {
+			if err := r.Reopen(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close closes every sink
+func (ss sinks) close() error {
+	var errs []error
+	for _, s := range ss {
+		if err := s.writer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newSink creates a sink from its configuration. Empty level and format
+// fall back to those of the logger; tag names the program in syslog and
+// journald entries.
+func newSink(config SinkConfig, format, timestampFormat, tag string) (*sink, error) {
+	s := &sink{name: config.Type + " sink"}
+	if config.Level != "" {
+		level, err := ParseLevel(config.Level)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		s.level = &level
+	}
+	if config.Format != "" {
+		format = config.Format
+	}
+	f, err := newFormatter(format, timestampFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.name, err)
+	}
+
+	switch config.Type {
+	case SinkFile:
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("%s: file_path is required", s.name)
+		}
+		s.name = "log file " + config.FilePath
+		fileWriter, err := newFileWriter(config)
+		if err != nil {
+			return nil, err
+		}
+		s.writer = &streamWriter{formatter: f, out: fileWriter, closer: fileWriter}
+	case SinkStdout:
+		s.writer = &streamWriter{formatter: f, out: os.Stdout}
+	case SinkStderr:
+		s.writer = &streamWriter{formatter: f, out: os.Stderr}
+	case SinkSyslog:
+		w, err := newSyslogWriter(config.Address, config.Facility, tag, f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		s.writer = w
+	case SinkJournald:
+		w, err := newJournaldWriter(config.Address, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+		s.writer = w
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q, must be one of %s, %s, %s, %s, %s",
+			config.Type, SinkFile, SinkStdout, SinkStderr, SinkSyslog, SinkJournald)
+	}
+	return s, nil
+}
+
+// newFileWriter creates a rotating writer, applying default limits
+func newFileWriter(config SinkConfig) (*rotateWriter, error) {
+	// Default values if not specified
+	maxSize := config.MaxSize
+	if maxSize <= 0 {
+		maxSize = 100 // Default 100MB
+	}
+
+	maxBackups := config.MaxBackups
+	if maxBackups <= 0 {
+		maxBackups = 5 // Default 5 backups
+	}
+
+	fileWriter, err := newRotateWriter(rotateOptions{
+		filePath:    config.FilePath,
+		maxSize:     maxSize,
+		maxBackups:  maxBackups,
+		maxAgeDays:  config.MaxAgeDays,
+		compress:    config.Compress,
+		rotateEvery: config.RotateEvery,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log writer: %w", err)
+	}
+	return fileWriter, nil
+}
+
+// defaultTag returns the name of the running program
+func defaultTag() string {
+	return filepath.Base(os.Args[0])
+}
+
+// streamWriter writes records as lines of JSON, text or logfmt
+type streamWriter struct {
+	formatter formatter
+	out       io.Writer
+	closer    io.Closer // nil for outputs the logger does not own
+}
+
+// write formats the record and writes it with a single call, so entries
+// from concurrent goroutines are not interleaved
+func (w *streamWriter) write(r *record) error {
+	line, err := w.formatter.line(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(line)
+	return err
+}
+
+// Reopen reopens the output if it is a log file
+func (w *streamWriter) Reopen() error {
+	if r, ok := w.closer.(interface{ Reopen() error }); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Close closes the output if the logger owns it
+func (w *streamWriter) Close() error {
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// LevelFatal is the slog level of entries logged with Fatal through a slog handler
+const LevelFatal = slog.Level(12)
+
+// slogHandler implements slog.Handler on top of a Logger, so entries logged
+// with log/slog are formatted and rotated like all others
+type slogHandler struct {
+	logger Logger
+	prefix string // qualifies attribute keys inside groups, e.g. "http."
+}
+
+// NewSlogHandler returns a slog.Handler writing to l. Attributes become fields,
+// and attributes inside groups are keyed by the dotted group path. Fields
+// attached to the context passed to slog are added as well.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled reports whether l writes entries of the given level
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(fromSlogLevel(level))
+}
+
+// Handle writes a record. Records above the error level are logged as errors;
+// a slog record never exits the program.
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]Field, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+
+	l := h.logger.WithContext(ctx)
+	switch {
+	case record.Level >= slog.LevelError:
+		l.Error(record.Message, fields...)
+	case record.Level >= slog.LevelWarn:
+		l.Warn(record.Message, fields...)
+	case record.Level >= slog.LevelInfo:
+		l.Info(record.Message, fields...)
+	default:
+		l.Debug(record.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every entry
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{logger: h.logger.WithFields(fields...), prefix: h.prefix}
+}
+
+// WithGroup returns a handler that qualifies the keys of later attributes with name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// appendAttr appends an attribute as fields, flattening groups
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		// Attributes of a group without a key are inlined
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+
+	value := a.Value.Any()
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: value})
+}
+
+// fromSlogLevel maps a slog level to the closest level at or below it
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level >= LevelFatal:
+		return FatalLevel
+	case level >= slog.LevelError:
+		return ErrorLevel
+	case level >= slog.LevelWarn:
+		return WarnLevel
+	case level >= slog.LevelInfo:
+		return InfoLevel
+	default:
+		return DebugLevel
+	}
+}
+
+// toSlogLevel maps a level to its slog equivalent
+func toSlogLevel(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return LevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// slogLogger implements Logger on top of a slog.Handler, for embedding in
+// programs that already log with log/slog
+type slogLogger struct {
+	handler slog.Handler
+	level   *levelVar // filters entries before the handler does
+}
+
+// NewFromSlog returns a Logger writing to h. Fatal entries are written at
+// LevelFatal before the program exits. Close does nothing; the owner of h
+// releases its resources. SetLevel can only narrow what h already writes.
+func NewFromSlog(h slog.Handler) Logger {
+	return &slogLogger{handler: h, level: newLevelVar(DebugLevel)}
+}
+
+// Debug logs a debug message
+func (l *slogLogger) Debug(msg string, fields ...Field) {
+	l.log(DebugLevel, msg, fields)
+}
+
+// Info logs an info message
+func (l *slogLogger) Info(msg string, fields ...Field) {
+	l.log(InfoLevel, msg, fields)
+}
+
+// Warn logs a warning message
+func (l *slogLogger) Warn(msg string, fields ...Field) {
+	l.log(WarnLevel, msg, fields)
+}
+
+// Error logs an error message
+func (l *slogLogger) Error(msg string, fields ...Field) {
+	l.log(ErrorLevel, msg, fields)
+}
+
+// Fatal logs a fatal message and exits
+func (l *slogLogger) Fatal(msg string, fields ...Field) {
+	l.log(FatalLevel, msg, fields)
+	os.Exit(1)
+}
+
+// WithField returns a new logger with the field added
+func (l *slogLogger) WithField(key string, value interface{}) Logger {
+	return l.WithFields(Field{Key: key, Value: value})
+}
+
+// WithFields returns a new logger with the fields added
+func (l *slogLogger) WithFields(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return &slogLogger{handler: l.handler.WithAttrs(toAttrs(fields)), level: l.level}
+}
+
+// WithContext returns a new logger with the fields attached to ctx added
+func (l *slogLogger) WithContext(ctx context.Context) Logger {
+	return l.WithFields(contextFields(ctx)...)
+}
+
+// Enabled reports whether the handler writes entries of the given level
+func (l *slogLogger) Enabled(level Level) bool {
+	return l.level.get() <= level && l.handler.Enabled(context.Background(), toSlogLevel(level))
+}
+
+// Level returns the minimum level passed on to the handler
+func (l *slogLogger) Level() Level {
+	return l.level.get()
+}
+
+// SetLevel changes the minimum level passed on to the handler
+func (l *slogLogger) SetLevel(level Level) {
+	l.level.set(level)
+}
+
+// WithLevel returns a new logger with its own minimum level
+func (l *slogLogger) WithLevel(level Level) Logger {
+	return &slogLogger{handler: l.handler, level: newLevelVar(level)}
+}
+
+// Reopen does nothing
+func (l *slogLogger) Reopen() error {
+	return nil
+}
+
+// Close does nothing
+func (l *slogLogger) Close() error {
+	return nil
+}
+
+// log writes a record to the handler
+func (l *slogLogger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	record := slog.NewRecord(time.Now(), toSlogLevel(level), msg, 0)
+	record.AddAttrs(toAttrs(fields)...)
+	l.handler.Handle(context.Background(), record)
+}
+
+// toAttrs converts fields to slog attributes
+func toAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return attrs
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultSyslogAddress is the local syslog socket
+const defaultSyslogAddress = "/dev/log"
+
+// maxSyslogMessage limits the size of a message so it fits in a datagram;
+// longer messages are truncated at a character boundary
+const maxSyslogMessage = 64 << 10
+
+// syslogFacilities maps facility names to their RFC 5424 codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities maps levels to RFC 5424 severities
+var syslogSeverities = map[Level]int{
+	DebugLevel: 7, // debug
+	InfoLevel:  6, // informational
+	WarnLevel:  4, // warning
+	ErrorLevel: 3, // error
+	FatalLevel: 2, // critical
+}
+
+// syslogWriter sends records to the local syslog daemon as RFC 5424 messages
+// over a unix socket. The message text is the entry as JSON, or the message
+// followed by logfmt fields for other formats; the timestamp is in the header.
+type syslogWriter struct {
+	address   string
+	facility  int
+	hostname  string
+	tag       string
+	formatter formatter
+
+	mu      sync.Mutex
+	conn    net.Conn
+	network string // "unixgram" or "unix"
+}
+
+// newSyslogWriter connects to the syslog socket at address
+func newSyslogWriter(address, facility, tag string, f formatter) (*syslogWriter, error) {
+	if address == "" {
+		address = defaultSyslogAddress
+	}
+	if facility == "" {
+		facility = "daemon"
+	}
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		address:   address,
+		facility:  code,
+		hostname:  syslogHeaderField(hostname, 255),
+		tag:       syslogHeaderField(tag, 48),
+		formatter: f,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the socket, preferring datagrams as syslog daemons expect
+func (w *syslogWriter) connect() error {
+	var err error
+	for _, network := range []string{"unixgram", "unix"} {
+		var conn net.Conn
+		if conn, err = net.Dial(network, w.address); err == nil {
+			w.conn = conn
+			w.network = network
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to connect to syslog at %s: %w", w.address, err)
+}
+
+// write sends a record, reconnecting once if the daemon was restarted
+func (w *syslogWriter) write(r *record) error {
+	msg, err := w.message(r)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if err = w.send(msg); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return err
+	}
+	return w.send(msg)
+}
+
+// send writes a message to the connection. Stream sockets need a trailing
+// newline to separate messages.
+func (w *syslogWriter) send(msg []byte) error {
+	if w.network == "unix" {
+		msg = append(msg, '\n')
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// message formats a record as an RFC 5424 message
+func (w *syslogWriter) message(r *record) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - - ",
+		w.facility*8+syslogSeverities[r.level],
+		r.time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname, w.tag, os.Getpid())
+
+	if w.formatter.format == FormatJSON {
+		line, err := w.formatter.line(r)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bytes.TrimSuffix(line, []byte("\n")))
+	} else {
+		buf.WriteString(r.message)
+		appendLogfmtFields(&buf, r.fields)
+	}
+
+	msg := buf.Bytes()
+	if len(msg) > maxSyslogMessage {
+		// Back off to the start of a character so the message stays valid UTF-8
+		n := maxSyslogMessage
+		for n > 0 && !utf8.RuneStart(msg[n]) {
+			n--
+		}
+		msg = msg[:n]
+	}
+	return msg, nil
+}
+
+// Close closes the connection
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogHeaderField restricts a header field to printable ASCII without
+// spaces and to limit characters, or "-" if empty, as RFC 5424 requires
+func syslogHeaderField(value string, limit int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > limit {
+		value = value[:limit]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
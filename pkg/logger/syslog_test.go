@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSyslogMessageTruncation(t *testing.T) {
+	w := &syslogWriter{facility: 3, hostname: "host", tag: "webhook-forge", formatter: formatter{format: FormatText}}
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"ascii", strings.Repeat("a", maxSyslogMessage)},
+		{"two-byte characters", strings.Repeat("é", maxSyslogMessage)},
+		{"three-byte characters", strings.Repeat("€", maxSyslogMessage)},
+		{"four-byte characters", strings.Repeat("😀", maxSyslogMessage)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := w.message(&record{time: time.Now(), level: InfoLevel, message: tt.message})
+			if err != nil {
+				t.Fatalf("message() error = %v", err)
+			}
+			if len(msg) > maxSyslogMessage {
+				t.Errorf("len(message()) = %d, want at most %d", len(msg), maxSyslogMessage)
+			}
+			if len(msg) < maxSyslogMessage-utf8.UTFMax {
+				t.Errorf("len(message()) = %d, want at least %d", len(msg), maxSyslogMessage-utf8.UTFMax)
+			}
+			if !utf8.Valid(msg) {
+				t.Errorf("message() is not valid UTF-8, ends with %q", msg[len(msg)-4:])
+			}
+		})
+	}
+}
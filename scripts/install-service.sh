@@ -113,6 +113,11 @@ ExecStart=$EXECUTABLE
 Restart=on-failure
 RestartSec=10
 Environment=CONFIG_PATH=$CONFIG_PATH
+# Output goes to the journal; unless the config sets log.file_path or
+# log.sinks, entries are sent with their fields using the native protocol
+StandardOutput=journal
+StandardError=journal
+SyslogIdentifier=$SERVICE_NAME
 
 [Install]
 WantedBy=multi-user.target
@@ -124,4 +129,5 @@ systemctl daemon-reload
 echo "Service installed successfully as $SERVICE_NAME.service"
 echo "To start the service, run: sudo systemctl start $SERVICE_NAME"
 echo "To enable it at boot, run: sudo systemctl enable $SERVICE_NAME"
-echo "To check the service status: sudo systemctl status $SERVICE_NAME" 
\ No newline at end of file
+echo "To check the service status: sudo systemctl status $SERVICE_NAME"
+echo "To follow the service logs: sudo journalctl -u $SERVICE_NAME -f" 
\ No newline at end of file